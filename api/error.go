@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+	"net/http"
+)
+
+// requestError is an error caused by a malformed request
+type requestError struct {
+	msg string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+var errReadOnlyField = &requestError{"field cannot be updated"}
+var errNoFields = &requestError{"no fields to update"}
+
+// errorBody is the body of an error response
+type errorBody struct {
+	Error      string           `json:"error"`
+	Violations []data.Violation `json:"violations,omitempty"`
+}
+
+// statusCode returns the HTTP status code of an error
+func statusCode(err error) int {
+	var reqErr *requestError
+	var validationErr *data.ValidationError
+	var versionErr *data.VersionConflictError
+
+	switch {
+	case errors.As(err, &reqErr):
+		return http.StatusBadRequest
+	case errors.As(err, &validationErr):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &versionErr):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, data.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, data.ErrForeignKey):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, data.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, data.ErrInvalidOptions):
+		return http.StatusBadRequest
+	case errors.Is(err, data.ErrConnection):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, data.ErrInterrupted):
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
+
+// writeError responds with the status code of an error and its message, which hides database details
+func writeError(w http.ResponseWriter, err error) {
+	body := errorBody{Error: err.Error()}
+	var validationErr *data.ValidationError
+
+	if errors.As(err, &validationErr) {
+		body.Violations = validationErr.Violations
+	}
+
+	writeJson(w, statusCode(err), body)
+}
+
+// writeJson responds with a status code and a JSON body.
+// Once the status code is written, errors can no longer be reported to the client, so encoding errors are dropped
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"github.com/brietsparks/xcrud/data"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// object is a JSON object of the OpenAPI document
+type object = map[string]interface{}
+
+// the fields that only the server sets, by JSON name.
+// The group of a membership is set from the path rather than the body
+var readOnlyFields = []string{"id", "groupId", "version", "createdAt", "updatedAt", "deletedAt", "linkedAt"}
+
+// the properties of the validate rules that are registered by the store
+var customRules = map[string]object{
+	"username": {"pattern": data.UsernamePattern.String()},
+}
+
+// OpenAPI returns the OpenAPI 3 document of the API.
+// The schemas of the resources are derived from the json and validate tags of their fields
+func OpenAPI() object {
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "xcrud",
+			"version": strings.TrimPrefix(basePath, "/v"),
+		},
+		"servers": []object{{"url": basePath}},
+		"paths":   paths(),
+		"components": object{
+			"schemas": object{
+				"User":            schemaOf(reflect.TypeOf(data.User{})),
+				"UserPatch":       schemaOf(reflect.TypeOf(data.User{}), userFields...),
+				"UserList":        schemaOf(reflect.TypeOf(data.UserList{})),
+				"Group":           schemaOf(reflect.TypeOf(data.Group{})),
+				"GroupPatch":      schemaOf(reflect.TypeOf(data.Group{}), groupFields...),
+				"GroupList":       schemaOf(reflect.TypeOf(data.GroupList{})),
+				"Membership":      schemaOf(reflect.TypeOf(data.Membership{})),
+				"MembershipPatch": schemaOf(reflect.TypeOf(data.Membership{}), membershipFields...),
+				"MembershipList":  schemaOf(reflect.TypeOf(data.MembershipList{})),
+				"Violation":       schemaOf(reflect.TypeOf(data.Violation{})),
+				"Error":           schemaOf(reflect.TypeOf(errorBody{})),
+			},
+			"parameters": object{
+				"id":     pathParam("id"),
+				"userId": pathParam("userId"),
+				"limit":  queryParam("limit", object{"type": "integer", "minimum": 1}, "the maximum number of items in a page"),
+				"cursor": queryParam("cursor", object{"type": "string"}, "the nextCursor of the previous page"),
+				"where": queryParam("where", object{"type": "array", "items": object{"type": "string"}},
+					"a filter such as lastName~=pee, with the operators =, !=, <, <=, >, >=, ^= and ~="),
+				"sort": queryParam("sort", object{"type": "string"},
+					"comma separated fields, each prefixed with - for descending order"),
+				"count": queryParam("count", object{"type": "boolean"}, "whether to include the total number of items"),
+				"since": queryParam("since", object{"type": "string", "format": "date-time"},
+					"only include items created or updated at or after the time"),
+				"policy": queryParam("policy", object{"type": "string", "enum": []string{"keep", "restrict", "cascade"}},
+					"what happens to the memberships of a deleted resource"),
+				"If-Match": object{
+					"name":        "If-Match",
+					"in":          "header",
+					"description": "the ETag of the resource, which makes an update fail if the resource has changed since",
+					"schema":      object{"type": "string"},
+				},
+			},
+			"responses": object{
+				"Error": object{
+					"description": "an error",
+					"content":     jsonContent("Error"),
+				},
+			},
+		},
+	}
+}
+
+// paths returns the operations of the routes of NewServer
+func paths() object {
+	list := []string{"limit", "cursor", "where", "sort", "count", "since"}
+
+	return object{
+		"/users": object{
+			"get":  operation("listUsers", "list users", list, "", http.StatusOK, "UserList"),
+			"post": operation("createUser", "create a user", nil, "User", http.StatusCreated, "User"),
+		},
+		"/users/{id}": object{
+			"parameters": refs("id"),
+			"get":        operation("getUser", "get a user", nil, "", http.StatusOK, "User"),
+			"patch":      operation("updateUser", "update the fields of a user that are in the body", []string{"If-Match"}, "UserPatch", http.StatusOK, "User"),
+			"delete":     operation("deleteUser", "delete a user", []string{"policy"}, "", http.StatusNoContent, ""),
+		},
+		"/users/{id}/groups": object{
+			"parameters": refs("id"),
+			"get":        operation("listGroupsByUser", "list the groups of a user", list, "", http.StatusOK, "GroupList"),
+		},
+		"/groups": object{
+			"get":  operation("listGroups", "list groups", list, "", http.StatusOK, "GroupList"),
+			"post": operation("createGroup", "create a group", nil, "Group", http.StatusCreated, "Group"),
+		},
+		"/groups/{id}": object{
+			"parameters": refs("id"),
+			"get":        operation("getGroup", "get a group", nil, "", http.StatusOK, "Group"),
+			"patch":      operation("updateGroup", "update the fields of a group that are in the body", []string{"If-Match"}, "GroupPatch", http.StatusOK, "Group"),
+			"delete":     operation("deleteGroup", "delete a group", []string{"policy"}, "", http.StatusNoContent, ""),
+		},
+		"/groups/{id}/users": object{
+			"parameters": refs("id"),
+			"get":        operation("listUsersByGroup", "list the users of a group", list, "", http.StatusOK, "UserList"),
+			"post":       operation("linkUser", "add a user to a group", nil, "Membership", http.StatusCreated, "Membership"),
+		},
+		"/groups/{id}/users/{userId}": object{
+			"parameters": refs("id", "userId"),
+			"get":        operation("getMembership", "get the membership of a user in a group", nil, "", http.StatusOK, "Membership"),
+			"patch":      operation("updateMembership", "update the role and attributes of a membership", nil, "MembershipPatch", http.StatusOK, "Membership"),
+			"delete":     operation("unlinkUser", "remove a user from a group", nil, "", http.StatusNoContent, ""),
+		},
+		"/memberships": object{
+			"get": operation("listMemberships", "list memberships", list, "", http.StatusOK, "MembershipList"),
+		},
+	}
+}
+
+// operation describes an operation whose body and successful response have the named schemas, if any
+func operation(id string, summary string, params []string, body string, status int, response string) object {
+	op := object{
+		"operationId": id,
+		"summary":     summary,
+		"responses": object{
+			"default": ref("responses", "Error"),
+		},
+	}
+
+	if len(params) > 0 {
+		op["parameters"] = refs(params...)
+	}
+
+	if body != "" {
+		op["requestBody"] = object{"required": true, "content": jsonContent(body)}
+	}
+
+	success := object{"description": http.StatusText(status)}
+
+	if response != "" {
+		success["content"] = jsonContent(response)
+	}
+
+	op["responses"].(object)[strconv.Itoa(status)] = success
+
+	return op
+}
+
+func ref(kind string, name string) object {
+	return object{"$ref": "#/components/" + kind + "/" + name}
+}
+
+func refs(params ...string) []object {
+	objects := make([]object, len(params))
+
+	for i, param := range params {
+		objects[i] = ref("parameters", param)
+	}
+
+	return objects
+}
+
+func jsonContent(schema string) object {
+	return object{"application/json": object{"schema": ref("schemas", schema)}}
+}
+
+func pathParam(name string) object {
+	return object{
+		"name":     name,
+		"in":       "path",
+		"required": true,
+		"schema":   object{"type": "integer", "format": "int64"},
+	}
+}
+
+func queryParam(name string, schema object, description string) object {
+	return object{
+		"name":        name,
+		"in":          "query",
+		"description": description,
+		"schema":      schema,
+	}
+}
+
+// schemaOf derives the schema of a struct type from the json and validate tags of its fields.
+// Given field names, the schema only has those fields and none of them are required, as in the body of a patch.
+// Otherwise fields with a required rule are required, and so are fields without validate tags that are never omitted
+func schemaOf(t reflect.Type, fields ...string) object {
+	properties := object{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+
+		if name == "" || name == "-" || (len(fields) > 0 && !includes(fields, f.Name)) {
+			continue
+		}
+
+		if len(fields) > 0 && includes(readOnlyFields, name) {
+			continue
+		}
+
+		schema := typeSchema(f.Type)
+		rules := f.Tag.Get("validate")
+
+		if rules != "" {
+			applyRules(schema, strings.Split(rules, ","))
+		}
+
+		if includes(readOnlyFields, name) {
+			schema["readOnly"] = true
+		}
+
+		// a patch clears optional fields that are null
+		if len(fields) > 0 && f.Type.Kind() == reflect.Ptr {
+			schema["nullable"] = true
+		}
+
+		properties[name] = schema
+
+		if len(fields) > 0 {
+			continue
+		}
+
+		if includes(strings.Split(rules, ","), "required") || (rules == "" && !includes(tag[1:], "omitempty")) {
+			required = append(required, name)
+		}
+	}
+
+	schema := object{"type": "object", "properties": properties, "additionalProperties": false}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// typeSchema returns the schema of a Go type, referring to the schemas of named structs
+func typeSchema(t reflect.Type) object {
+	if t == reflect.TypeOf(time.Time{}) {
+		return object{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Struct:
+		return ref("schemas", t.Name())
+	case reflect.Slice:
+		return object{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": true}
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int64:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int32:
+		return object{"type": "integer", "format": "int32"}
+	}
+
+	return object{}
+}
+
+// applyRules adds the constraints of validate rules to a schema.
+// Rules without an equivalent, such as omitempty, are skipped
+func applyRules(schema object, rules []string) {
+	for _, rule := range rules {
+		tag, param, _ := strings.Cut(rule, "=")
+
+		switch tag {
+		case "lte", "max":
+			schema[bound(schema, "max")] = number(param)
+		case "gte", "min":
+			schema[bound(schema, "min")] = number(param)
+		case "email":
+			schema["format"] = "email"
+		case "oneof":
+			schema["enum"] = strings.Fields(param)
+		default:
+			for k, v := range customRules[tag] {
+				schema[k] = v
+			}
+		}
+	}
+}
+
+// bound returns the keyword of a min or max bound, which limits the length of strings and arrays and the value of numbers
+func bound(schema object, prefix string) string {
+	switch schema["type"] {
+	case "string":
+		return prefix + "Length"
+	case "array":
+		return prefix + "Items"
+	}
+
+	return prefix + "imum"
+}
+
+func number(param string) interface{} {
+	if n, err := strconv.Atoi(param); err == nil {
+		return n
+	}
+
+	f, _ := strconv.ParseFloat(param, 64)
+
+	return f
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/brietsparks/xcrud/data"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pathId parses an id path parameter
+func pathId(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+
+	if err != nil {
+		return 0, &requestError{"invalid " + name}
+	}
+
+	return id, nil
+}
+
+// membershipIds parses the group and user ids of a membership path
+func membershipIds(r *http.Request) (int64, int64, error) {
+	groupId, err := pathId(r, "id")
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	userId, err := pathId(r, "userId")
+
+	return groupId, userId, err
+}
+
+// listOptions parses the query parameters limit, cursor, where, sort, count and since like the CLI's list flags
+func listOptions(r *http.Request) (data.ListOptions, error) {
+	q := r.URL.Query()
+	opts := data.ListOptions{Cursor: q.Get("cursor")}
+
+	if limit := q.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+
+		if err != nil || l < 1 {
+			return opts, &requestError{"invalid limit"}
+		}
+
+		opts.Limit = l
+	}
+
+	if count := q.Get("count"); count != "" {
+		c, err := strconv.ParseBool(count)
+
+		if err != nil {
+			return opts, &requestError{"invalid count"}
+		}
+
+		opts.Count = c
+	}
+
+	for _, expr := range q["where"] {
+		filter, err := data.ParseFilter(expr)
+
+		if err != nil {
+			return opts, &requestError{err.Error()}
+		}
+
+		opts.Filters = append(opts.Filters, filter)
+	}
+
+	if sort := q.Get("sort"); sort != "" {
+		for _, expr := range strings.Split(sort, ",") {
+			s := data.ParseSort(expr)
+
+			if s.Field == "" {
+				return opts, &requestError{"invalid sort"}
+			}
+
+			opts.Sort = append(opts.Sort, s)
+		}
+	}
+
+	if since := q.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+
+		if err != nil {
+			return opts, &requestError{"invalid since"}
+		}
+
+		opts.Since = t
+	}
+
+	return opts, nil
+}
+
+// deletePolicy parses the policy query parameter of a delete, which is keep (the default), restrict or cascade
+func deletePolicy(r *http.Request) (data.DeletePolicy, error) {
+	switch r.URL.Query().Get("policy") {
+	case "", "keep":
+		return data.DeleteKeep, nil
+	case "restrict":
+		return data.DeleteRestrict, nil
+	case "cascade":
+		return data.DeleteCascade, nil
+	}
+
+	return 0, &requestError{"invalid policy"}
+}
+
+// etag formats a version as an entity tag, which If-Match headers of updates refer to
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatch parses the version in an If-Match header, reporting whether there is one
+func ifMatch(r *http.Request) (int64, bool, error) {
+	etag := r.Header.Get("If-Match")
+
+	if etag == "" {
+		return 0, false, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(etag, `"`), 10, 64)
+
+	if err != nil {
+		return 0, false, &requestError{"invalid If-Match version"}
+	}
+
+	return version, true, nil
+}
+
+// decodeBody decodes a JSON body into v, rejecting fields that v does not have
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return &requestError{"invalid body: " + err.Error()}
+	}
+
+	return nil
+}
+
+// decodePatch decodes a JSON object into v, which points to a struct,
+// and returns the names of the struct fields that are present in the object.
+// Fields that are not in updatable cannot be present, and at least one field must be
+func decodePatch(r *http.Request, v interface{}, updatable []string) ([]string, error) {
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		return nil, &requestError{"invalid body"}
+	}
+
+	var present map[string]json.RawMessage
+
+	if err := json.Unmarshal(body, &present); err != nil {
+		return nil, &requestError{"invalid body: " + err.Error()}
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := decodeBody(r, v); err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeOf(v).Elem()
+	var fields []string
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+
+		if _, ok := present[name]; !ok || name == "" || name == "-" {
+			continue
+		}
+
+		if !includes(updatable, f.Name) {
+			return nil, errReadOnlyField
+		}
+
+		fields = append(fields, f.Name)
+	}
+
+	if len(fields) == 0 {
+		return nil, errNoFields
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"github.com/brietsparks/xcrud/data"
+	"net/http"
+)
+
+// the fields of users and groups that can be updated, as the store defines them
+var userFields = data.UpdatableFields[data.User]()
+var groupFields = data.UpdatableFields[data.Group]()
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) error {
+	opts, err := listOptions(r)
+
+	if err != nil {
+		return err
+	}
+
+	users, err := s.store.ListUsersContext(r.Context(), opts)
+
+	if err != nil {
+		return err
+	}
+
+	writeJson(w, http.StatusOK, users)
+
+	return nil
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) error {
+	u := &data.User{}
+
+	if err := decodeBody(r, u); err != nil {
+		return err
+	}
+
+	u, err := s.store.CreateUserContext(r.Context(), u)
+
+	if err != nil {
+		return err
+	}
+
+	writeJson(w, http.StatusCreated, u)
+
+	return nil
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	u, err := s.store.GetUserByIdContext(r.Context(), id)
+
+	if err != nil {
+		return err
+	}
+
+	if u == nil {
+		return data.ErrNotFound
+	}
+
+	w.Header().Set("ETag", etag(u.Version))
+	writeJson(w, http.StatusOK, u)
+
+	return nil
+}
+
+// updateUser updates the fields that are present in the body.
+// An If-Match header holding the user's version makes the update conditional on the version
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	u := &data.User{}
+	fields, err := decodePatch(r, u, userFields)
+
+	if err != nil {
+		return err
+	}
+
+	version, ok, err := ifMatch(r)
+
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		err = s.store.UpdateUserIfVersionContext(r.Context(), id, version, u, fields...)
+	} else {
+		err = s.store.UpdateUserContext(r.Context(), id, u, fields...)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.getUser(w, r)
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	policy, err := deletePolicy(r)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.DeleteUserContext(r.Context(), id, policy); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) listGroupsByUser(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	opts, err := listOptions(r)
+
+	if err != nil {
+		return err
+	}
+
+	groups, err := s.store.ListGroupsByUserIdContext(r.Context(), id, opts)
+
+	if err != nil {
+		return err
+	}
+
+	writeJson(w, http.StatusOK, groups)
+
+	return nil
+}
+
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) error {
+	opts, err := listOptions(r)
+
+	if err != nil {
+		return err
+	}
+
+	groups, err := s.store.ListGroupsContext(r.Context(), opts)
+
+	if err != nil {
+		return err
+	}
+
+	writeJson(w, http.StatusOK, groups)
+
+	return nil
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request) error {
+	g := &data.Group{}
+
+	if err := decodeBody(r, g); err != nil {
+		return err
+	}
+
+	g, err := s.store.CreateGroupContext(r.Context(), g)
+
+	if err != nil {
+		return err
+	}
+
+	writeJson(w, http.StatusCreated, g)
+
+	return nil
+}
+
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	g, err := s.store.GetGroupByIdContext(r.Context(), id)
+
+	if err != nil {
+		return err
+	}
+
+	if g == nil {
+		return data.ErrNotFound
+	}
+
+	w.Header().Set("ETag", etag(g.Version))
+	writeJson(w, http.StatusOK, g)
+
+	return nil
+}
+
+// updateGroup updates the fields that are present in the body.
+// An If-Match header holding the group's version makes the update conditional on the version
+func (s *Server) updateGroup(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	g := &data.Group{}
+	fields, err := decodePatch(r, g, groupFields)
+
+	if err != nil {
+		return err
+	}
+
+	version, ok, err := ifMatch(r)
+
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		err = s.store.UpdateGroupIfVersionContext(r.Context(), id, version, g, fields...)
+	} else {
+		err = s.store.UpdateGroupContext(r.Context(), id, g, fields...)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.getGroup(w, r)
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	policy, err := deletePolicy(r)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.DeleteGroupContext(r.Context(), id, policy); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) listUsersByGroup(w http.ResponseWriter, r *http.Request) error {
+	id, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	opts, err := listOptions(r)
+
+	if err != nil {
+		return err
+	}
+
+	users, err := s.store.ListUsersByGroupIdContext(r.Context(), id, opts)
+
+	if err != nil {
+		return err
+	}
+
+	writeJson(w, http.StatusOK, users)
+
+	return nil
+}
+
+// linkUser adds the user in the body to a group, with the attributes in the body and its role or the member role
+func (s *Server) linkUser(w http.ResponseWriter, r *http.Request) error {
+	groupId, err := pathId(r, "id")
+
+	if err != nil {
+		return err
+	}
+
+	m := &data.Membership{}
+
+	if err := decodeBody(r, m); err != nil {
+		return err
+	}
+
+	m.GroupId = groupId
+	m, err = s.store.CreateMembershipContext(r.Context(), m)
+
+	if err != nil {
+		return err
+	}
+
+	writeJson(w, http.StatusCreated, m)
+
+	return nil
+}
+
+func (s *Server) getMembership(w http.ResponseWriter, r *http.Request) error {
+	groupId, userId, err := membershipIds(r)
+
+	if err != nil {
+		return err
+	}
+
+	m, err := s.store.GetMembershipContext(r.Context(), groupId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if m == nil {
+		return data.ErrNotFound
+	}
+
+	writeJson(w, http.StatusOK, m)
+
+	return nil
+}
+
+// the fields of a membership that can be updated
+var membershipFields = []string{"Role", "Attributes"}
+
+// updateMembership changes the role and attributes of a membership, if they are present in the body
+func (s *Server) updateMembership(w http.ResponseWriter, r *http.Request) error {
+	groupId, userId, err := membershipIds(r)
+
+	if err != nil {
+		return err
+	}
+
+	m := &data.Membership{}
+	fields, err := decodePatch(r, m, membershipFields)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.UpdateMembershipContext(r.Context(), groupId, userId, m, fields...); err != nil {
+		return err
+	}
+
+	return s.getMembership(w, r)
+}
+
+func (s *Server) unlinkUser(w http.ResponseWriter, r *http.Request) error {
+	groupId, userId, err := membershipIds(r)
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.UnlinkGroupFromUserContext(r.Context(), groupId, userId); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) listMemberships(w http.ResponseWriter, r *http.Request) error {
+	opts, err := listOptions(r)
+
+	if err != nil {
+		return err
+	}
+
+	memberships, err := s.store.ListMembershipsContext(r.Context(), opts)
+
+	if err != nil {
+		return err
+	}
+
+	writeJson(w, http.StatusOK, memberships)
+
+	return nil
+}
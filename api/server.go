@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"github.com/brietsparks/xcrud/data"
+	"net/http"
+	"strings"
+)
+
+// Store is the part of data.Store that the server exposes
+type Store interface {
+	CreateUserContext(ctx context.Context, u *data.User) (*data.User, error)
+	GetUserByIdContext(ctx context.Context, id int64) (*data.User, error)
+	UpdateUserContext(ctx context.Context, id int64, u *data.User, fields ...string) error
+	UpdateUserIfVersionContext(ctx context.Context, id int64, version int64, u *data.User, fields ...string) error
+	DeleteUserContext(ctx context.Context, id int64, policy ...data.DeletePolicy) error
+	ListUsersContext(ctx context.Context, opts data.ListOptions) (*data.UserList, error)
+
+	CreateGroupContext(ctx context.Context, g *data.Group) (*data.Group, error)
+	GetGroupByIdContext(ctx context.Context, id int64) (*data.Group, error)
+	UpdateGroupContext(ctx context.Context, id int64, g *data.Group, fields ...string) error
+	UpdateGroupIfVersionContext(ctx context.Context, id int64, version int64, g *data.Group, fields ...string) error
+	DeleteGroupContext(ctx context.Context, id int64, policy ...data.DeletePolicy) error
+	ListGroupsContext(ctx context.Context, opts data.ListOptions) (*data.GroupList, error)
+
+	ListUsersByGroupIdContext(ctx context.Context, groupId int64, opts data.ListOptions) (*data.UserList, error)
+	ListGroupsByUserIdContext(ctx context.Context, userId int64, opts data.ListOptions) (*data.GroupList, error)
+	ListMembershipsContext(ctx context.Context, opts data.ListOptions) (*data.MembershipList, error)
+	CreateMembershipContext(ctx context.Context, m *data.Membership) (*data.Membership, error)
+	GetMembershipContext(ctx context.Context, groupId int64, userId int64) (*data.Membership, error)
+	UpdateMembershipContext(ctx context.Context, groupId int64, userId int64, m *data.Membership, fields ...string) error
+	UnlinkGroupFromUserContext(ctx context.Context, groupId int64, userId int64) error
+}
+
+// the prefix of every route, which changes with incompatible changes to the API
+const basePath = "/v1"
+
+// Server serves a Store as a REST API with JSON bodies
+type Server struct {
+	store Store
+	mux   *http.ServeMux
+}
+
+// NewServer creates a Server that reads and writes resources through store
+func NewServer(store Store) *Server {
+	s := &Server{store: store, mux: http.NewServeMux()}
+
+	s.handle("GET /users", s.listUsers)
+	s.handle("POST /users", s.createUser)
+	s.handle("GET /users/{id}", s.getUser)
+	s.handle("PATCH /users/{id}", s.updateUser)
+	s.handle("DELETE /users/{id}", s.deleteUser)
+	s.handle("GET /users/{id}/groups", s.listGroupsByUser)
+
+	s.handle("GET /groups", s.listGroups)
+	s.handle("POST /groups", s.createGroup)
+	s.handle("GET /groups/{id}", s.getGroup)
+	s.handle("PATCH /groups/{id}", s.updateGroup)
+	s.handle("DELETE /groups/{id}", s.deleteGroup)
+	s.handle("GET /groups/{id}/users", s.listUsersByGroup)
+	s.handle("POST /groups/{id}/users", s.linkUser)
+	s.handle("GET /groups/{id}/users/{userId}", s.getMembership)
+	s.handle("PATCH /groups/{id}/users/{userId}", s.updateMembership)
+	s.handle("DELETE /groups/{id}/users/{userId}", s.unlinkUser)
+
+	s.handle("GET /memberships", s.listMemberships)
+
+	// the document describes the routes below basePath rather than being one of them
+	s.mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, OpenAPI())
+	})
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handlerFunc handles a request, returning an error to respond with instead of writing a response
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// handle registers a handler for a method and a path below basePath
+func (s *Server) handle(pattern string, h handlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+
+	s.mux.HandleFunc(method+" "+basePath+path, func(w http.ResponseWriter, r *http.Request) {
+		if err := h(w, r); err != nil {
+			writeError(w, err)
+		}
+	})
+}
+
+func includes(values []string, val string) bool {
+	for _, v := range values {
+		if v == val {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/brietsparks/xcrud/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"os"
+	"testing"
+)
+
+var update bool
+
+func init() {
+	flag.BoolVar(&update, "update", false, "rewrite the golden files")
+}
+
+const openAPIGolden = "testdata/openapi.json"
+
+// TestOpenAPI fails when the document changes, such as when a model changes.
+// Once the change is intended, rewrite the golden file with: go test ./api/tests -run TestOpenAPI -update
+func TestOpenAPI(t *testing.T) {
+	doc, err := json.MarshalIndent(api.OpenAPI(), "", "  ")
+	require.NoError(t, err)
+	doc = append(doc, '\n')
+
+	if update {
+		require.NoError(t, os.WriteFile(openAPIGolden, doc, 0644))
+	}
+
+	golden, err := os.ReadFile(openAPIGolden)
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), string(doc), "the OpenAPI document has changed, run the test with -update if that is intended")
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	rec := do(t, newFakeStore(), "GET", "/openapi.json", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	golden, err := os.ReadFile(openAPIGolden)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(golden), rec.Body.String())
+}
@@ -0,0 +1,361 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/brietsparks/xcrud/api"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeStore records the calls of the server and returns canned results.
+// Methods that a test does not expect panic through the nil embedded Store
+type fakeStore struct {
+	api.Store
+
+	users       map[int64]*data.User
+	groups      map[int64]*data.Group
+	memberships map[[2]int64]*data.Membership
+	err         error
+
+	fields  []string
+	version int64
+	opts    data.ListOptions
+	policy  data.DeletePolicy
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:       map[int64]*data.User{1: {Id: 1, FirstName: "Bo", LastName: "Peep", Version: 3}},
+		groups:      map[int64]*data.Group{2: {Id: 2, Name: "A", Version: 1}},
+		memberships: map[[2]int64]*data.Membership{{2, 1}: {GroupId: 2, UserId: 1, Role: data.RoleMember}},
+	}
+}
+
+func (f *fakeStore) CreateUserContext(ctx context.Context, u *data.User) (*data.User, error) {
+	if u.FirstName == "" {
+		return nil, &data.ValidationError{Violations: []data.Violation{
+			{Field: "firstName", Rule: "required", Message: "firstName is required"},
+		}}
+	}
+
+	u.Id = 10
+	u.Version = 1
+
+	return u, f.err
+}
+
+func (f *fakeStore) GetUserByIdContext(ctx context.Context, id int64) (*data.User, error) {
+	return f.users[id], f.err
+}
+
+func (f *fakeStore) UpdateUserContext(ctx context.Context, id int64, u *data.User, fields ...string) error {
+	f.fields = fields
+
+	if f.err != nil {
+		return f.err
+	}
+
+	f.users[id].LastName = u.LastName
+
+	return nil
+}
+
+func (f *fakeStore) UpdateUserIfVersionContext(ctx context.Context, id int64, version int64, u *data.User, fields ...string) error {
+	f.version = version
+
+	if version != f.users[id].Version {
+		return &data.VersionConflictError{Expected: version, Actual: f.users[id].Version}
+	}
+
+	return f.UpdateUserContext(ctx, id, u, fields...)
+}
+
+func (f *fakeStore) DeleteUserContext(ctx context.Context, id int64, policy ...data.DeletePolicy) error {
+	f.policy = policy[0]
+
+	return f.err
+}
+
+func (f *fakeStore) ListUsersContext(ctx context.Context, opts data.ListOptions) (*data.UserList, error) {
+	f.opts = opts
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &data.UserList{Users: []data.User{*f.users[1]}}, nil
+}
+
+func (f *fakeStore) ListUsersByGroupIdContext(ctx context.Context, groupId int64, opts data.ListOptions) (*data.UserList, error) {
+	f.opts = opts
+
+	return &data.UserList{Users: []data.User{*f.users[1]}}, f.err
+}
+
+func (f *fakeStore) CreateMembershipContext(ctx context.Context, m *data.Membership) (*data.Membership, error) {
+	key := [2]int64{m.GroupId, m.UserId}
+
+	if _, ok := f.memberships[key]; ok {
+		return nil, &data.Error{Msg: data.ErrGroupUserAlreadyLinked, Kind: data.ErrConflict}
+	}
+
+	f.memberships[key] = m
+
+	return m, nil
+}
+
+func (f *fakeStore) GetMembershipContext(ctx context.Context, groupId int64, userId int64) (*data.Membership, error) {
+	return f.memberships[[2]int64{groupId, userId}], nil
+}
+
+func (f *fakeStore) UpdateMembershipContext(ctx context.Context, groupId int64, userId int64, m *data.Membership, fields ...string) error {
+	stored, ok := f.memberships[[2]int64{groupId, userId}]
+
+	if !ok {
+		return data.ErrNotFound
+	}
+
+	f.fields = fields
+
+	for _, field := range fields {
+		if field == "Role" {
+			stored.Role = m.Role
+		} else {
+			stored.Attributes = m.Attributes
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeStore) UnlinkGroupFromUserContext(ctx context.Context, groupId int64, userId int64) error {
+	key := [2]int64{groupId, userId}
+
+	if _, ok := f.memberships[key]; !ok {
+		return data.ErrNotFound
+	}
+
+	delete(f.memberships, key)
+
+	return nil
+}
+
+func do(t *testing.T, store api.Store, method string, path string, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+
+	for i := 0; i < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	api.NewServer(store).ServeHTTP(rec, req)
+
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+}
+
+func TestCreateUser(t *testing.T) {
+	store := newFakeStore()
+
+	rec := do(t, store, "POST", "/v1/users", `{"firstName":"Jo","lastName":"Jackson"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var u data.User
+	decode(t, rec, &u)
+	assert.Equal(t, int64(10), u.Id)
+	assert.Equal(t, "Jo", u.FirstName)
+
+	rec = do(t, store, "POST", "/v1/users", `{"lastName":"Jackson"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{
+		"error":"invalid resource: firstName is required",
+		"violations":[{"field":"firstName","rule":"required","message":"firstName is required"}]
+	}`, rec.Body.String())
+
+	rec = do(t, store, "POST", "/v1/users", `{"firstName":"Jo","nickname":"J"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetUser(t *testing.T) {
+	store := newFakeStore()
+
+	rec := do(t, store, "GET", "/v1/users/1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	rec = do(t, store, "GET", "/v1/users/1000", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":"resource does not exist"}`, rec.Body.String())
+
+	rec = do(t, store, "GET", "/v1/users/abc", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(t, store, "GET", "/users/1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(t, store, "PUT", "/v1/users/1", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestUpdateUser(t *testing.T) {
+	store := newFakeStore()
+
+	rec := do(t, store, "PATCH", "/v1/users/1", `{"lastName":"Jackson"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"LastName"}, store.fields)
+
+	var u data.User
+	decode(t, rec, &u)
+	assert.Equal(t, "Jackson", u.LastName)
+
+	rec = do(t, store, "PATCH", "/v1/users/1", `{"lastName":"Peep"}`, "If-Match", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, int64(2), store.version)
+
+	rec = do(t, store, "PATCH", "/v1/users/1", `{"lastName":"Peep"}`, "If-Match", `"3"`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(t, store, "PATCH", "/v1/users/1", `[]`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(t, store, "PATCH", "/v1/users/1", `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"no fields to update"}`, rec.Body.String())
+
+	// fields that the store does not update are rejected before reaching it
+	store.fields = nil
+
+	for _, body := range []string{`{"id":2}`, `{"lastName":"Peep","version":5}`, `{"createdAt":"2019-11-06T09:30:12Z"}`} {
+		rec = do(t, store, "PATCH", "/v1/users/1", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		assert.JSONEq(t, `{"error":"field cannot be updated"}`, rec.Body.String(), body)
+	}
+
+	assert.Nil(t, store.fields)
+}
+
+func TestUpdateGroupReadOnlyFields(t *testing.T) {
+	for _, body := range []string{`{"id":3}`, `{"name":"B","updatedAt":"2019-11-06T09:30:12Z"}`, `{"parentId":1}`} {
+		rec := do(t, newFakeStore(), "PATCH", "/v1/groups/2", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	store := newFakeStore()
+
+	rec := do(t, store, "DELETE", "/v1/users/1?policy=cascade", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, data.DeleteCascade, store.policy)
+
+	rec = do(t, store, "DELETE", "/v1/users/1?policy=never", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	store.err = &data.MembershipsExistError{Memberships: []data.Membership{{GroupId: 2, UserId: 1}}}
+	rec = do(t, store, "DELETE", "/v1/users/1?policy=restrict", "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestListUsers(t *testing.T) {
+	store := newFakeStore()
+
+	rec := do(t, store, "GET", "/v1/users?limit=5&where=lastName~%3Dpee&where=id>0&sort=-firstName&count=true", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, data.ListOptions{
+		Limit: 5,
+		Count: true,
+		Filters: []data.Filter{
+			{Field: "lastName", Op: data.OpContains, Value: "pee"},
+			{Field: "id", Op: data.OpGt, Value: "0"},
+		},
+		Sort: []data.Sort{{Field: "firstName", Desc: true}},
+	}, store.opts)
+
+	for _, query := range []string{"limit=many", "limit=0", "limit=-1", "where=garbage", "sort=,firstName", "sort=-"} {
+		rec = do(t, store, "GET", "/v1/users?"+query, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+
+	rec = do(t, store, "GET", "/v1/users?where=garbage", "")
+	assert.JSONEq(t, `{"error":"failed to parse filter \"garbage\""}`, rec.Body.String())
+
+	store.err = &data.Error{Msg: data.ErrInvalidCursor, Kind: data.ErrInvalidOptions}
+	rec = do(t, store, "GET", "/v1/users?cursor=abc", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"invalid cursor"}`, rec.Body.String())
+
+	store.err = data.NewError(&pq.Error{Code: "57014"})
+	rec = do(t, store, "GET", "/v1/users", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	store.err = data.NewError(context.DeadlineExceeded)
+	rec = do(t, store, "GET", "/v1/users", "")
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+
+	store.err = &data.Error{Msg: data.ErrConnectionFailed, Kind: data.ErrConnection}
+	rec = do(t, store, "GET", "/v1/users", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	store.err = &data.Error{Msg: data.ErrUnknown}
+	rec = do(t, store, "GET", "/v1/users", "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"error":"unspecified database error"}`, rec.Body.String())
+}
+
+func TestGroupUsers(t *testing.T) {
+	store := newFakeStore()
+
+	rec := do(t, store, "GET", "/v1/groups/2/users?limit=1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, store.opts.Limit)
+
+	var users data.UserList
+	decode(t, rec, &users)
+	assert.Len(t, users.Users, 1)
+
+	rec = do(t, store, "POST", "/v1/groups/2/users", `{"userId":5,"role":"admin","attributes":{"title":"lead"}}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// the attributes are written with the membership
+	var m data.Membership
+	decode(t, rec, &m)
+	assert.Equal(t, data.Membership{GroupId: 2, UserId: 5, Role: data.RoleAdmin, Attributes: data.Attributes{"title": "lead"}}, m)
+
+	rec = do(t, store, "POST", "/v1/groups/2/users", `{"userId":5}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.JSONEq(t, `{"error":"group already linked to user"}`, rec.Body.String())
+
+	rec = do(t, store, "PATCH", "/v1/groups/2/users/5", `{"userId":6}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// the role and attributes are updated together
+	rec = do(t, store, "PATCH", "/v1/groups/2/users/5", `{"role":"owner","attributes":{"title":"head"}}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"Role", "Attributes"}, store.fields)
+
+	decode(t, rec, &m)
+	assert.Equal(t, data.Membership{GroupId: 2, UserId: 5, Role: data.RoleOwner, Attributes: data.Attributes{"title": "head"}}, m)
+
+	rec = do(t, store, "PATCH", "/v1/groups/2/users/6", `{"role":"owner"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(t, store, "GET", "/v1/groups/2/users/6", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = do(t, store, "DELETE", "/v1/groups/2/users/5", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = do(t, store, "DELETE", "/v1/groups/2/users/5", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recordWriter writes a stream of records in one of the supported formats
+type recordWriter interface {
+	Write(record interface{}) error
+	Close() error
+}
+
+// newRecordWriter returns a writer of records of the model's type in the format "csv", "json" or "ndjson"
+func newRecordWriter(format string, w io.Writer, model interface{}) (recordWriter, error) {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		return &csvWriter{w: cw, fields: jsonFields(reflect.TypeOf(model))}, nil
+	case "json":
+		return &jsonWriter{w: w}, nil
+	case "ndjson":
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	fields      []field
+	wroteHeader bool
+}
+
+func (cw *csvWriter) Write(record interface{}) error {
+	if !cw.wroteHeader {
+		if err := cw.w.Write(fieldNames(cw.fields)); err != nil {
+			return err
+		}
+
+		cw.wroteHeader = true
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(record))
+	row := make([]string, len(cw.fields))
+
+	for i, f := range cw.fields {
+		s, err := formatValue(v.FieldByIndex(f.index))
+
+		if err != nil {
+			return err
+		}
+
+		row[i] = s
+	}
+
+	return cw.w.Write(row)
+}
+
+func (cw *csvWriter) Close() error {
+	if !cw.wroteHeader {
+		if err := cw.w.Write(fieldNames(cw.fields)); err != nil {
+			return err
+		}
+	}
+
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (jw *jsonWriter) Write(record interface{}) error {
+	j, err := json.Marshal(record)
+
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+
+	if jw.count == 0 {
+		sep = "[\n"
+	}
+
+	jw.count++
+	_, err = fmt.Fprintf(jw.w, "%s%s", sep, j)
+
+	return err
+}
+
+func (jw *jsonWriter) Close() error {
+	end := "\n]\n"
+
+	if jw.count == 0 {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(jw.w, end)
+
+	return err
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (nw *ndjsonWriter) Write(record interface{}) error {
+	return nw.enc.Encode(record)
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
+
+// readRecords decodes records of the model's type from the format "csv", "json" or "ndjson".
+// fn is called with each record, as a pointer to a new value of the model's type, and its 1-based row number.
+// A record that cannot be decoded is passed to fn with its error, while malformed input aborts reading
+func readRecords(format string, r io.Reader, model interface{}, fn func(row int, record interface{}, err error) error) error {
+	t := reflect.TypeOf(model)
+
+	switch format {
+	case "csv":
+		return readCsv(r, t, fn)
+	case "json":
+		return readJson(r, t, fn)
+	case "ndjson":
+		return readNdjson(r, t, fn)
+	}
+
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+func readCsv(r io.Reader, t reflect.Type, fn func(int, interface{}, error) error) error {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+
+	if err == io.EOF {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	fields := map[string]field{}
+
+	for _, f := range jsonFields(t) {
+		fields[f.name] = f
+	}
+
+	for row := 1; ; row++ {
+		values, err := cr.Read()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		record := reflect.New(t)
+
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		if err == nil {
+			for i, name := range header {
+				f, ok := fields[strings.TrimSpace(name)]
+
+				if !ok || i >= len(values) {
+					continue
+				}
+
+				if err = parseValue(record.Elem().FieldByIndex(f.index), values[i]); err != nil {
+					err = fmt.Errorf("invalid %s: %w", f.name, err)
+					break
+				}
+			}
+		}
+
+		if err := fn(row, record.Interface(), err); err != nil {
+			return err
+		}
+	}
+}
+
+func readJson(r io.Reader, t reflect.Type, fn func(int, interface{}, error) error) error {
+	dec := json.NewDecoder(r)
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return errors.New("failed to read json: expected an array of records")
+	}
+
+	for row := 1; dec.More(); row++ {
+		record := reflect.New(t)
+		err := dec.Decode(record.Interface())
+
+		var typeErr *json.UnmarshalTypeError
+
+		if err != nil && !errors.As(err, &typeErr) {
+			return fmt.Errorf("failed to read json: %w", err)
+		}
+
+		if err := fn(row, record.Interface(), err); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readNdjson(r io.Reader, t reflect.Type, fn func(int, interface{}, error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for row := 1; scanner.Scan(); {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		record := reflect.New(t)
+		err := json.Unmarshal([]byte(line), record.Interface())
+
+		if err := fn(row, record.Interface(), err); err != nil {
+			return err
+		}
+
+		row++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ndjson: %w", err)
+	}
+
+	return nil
+}
+
+// field is a struct field that is serialized under its json tag name
+type field struct {
+	name  string
+	index []int
+}
+
+func jsonFields(t reflect.Type) []field {
+	var fields []field
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		fields = append(fields, field{name: name, index: f.Index})
+	}
+
+	return fields
+}
+
+func fieldNames(fields []field) []string {
+	names := make([]string, len(fields))
+
+	for i, f := range fields {
+		names[i] = f.name
+	}
+
+	return names
+}
+
+// formatValue formats a field value as a csv cell
+func formatValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	}
+
+	j, err := json.Marshal(v.Interface())
+
+	return string(j), err
+}
+
+// parseValue sets a field value from a csv cell. An empty cell leaves pointer fields nil
+func parseValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if s == "" {
+			return nil
+		}
+
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	if _, ok := v.Interface().(time.Time); ok {
+		if s == "" {
+			return nil
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, s)
+
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			return nil
+		}
+
+		i, err := strconv.ParseInt(s, 10, 64)
+
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+		return nil
+	}
+
+	if s == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(s), v.Addr().Interface())
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+)
+
+// exit codes of failed commands by the class of their error, so that scripts can branch on them
+const (
+	ExitFailure    = 1
+	ExitNotFound   = 3
+	ExitInvalid    = 4
+	ExitConflict   = 5
+	ExitConnection = 6
+)
+
+// ExitCode returns the exit code of a command that failed with err
+func ExitCode(err error) int {
+	var validationErr *data.ValidationError
+
+	switch {
+	case errors.Is(err, data.ErrNotFound), errors.Is(err, data.ErrForeignKey):
+		return ExitNotFound
+	case errors.As(err, &validationErr), errors.Is(err, data.ErrInvalidOptions):
+		return ExitInvalid
+	case errors.Is(err, data.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, data.ErrConnection):
+		return ExitConnection
+	}
+
+	return ExitFailure
+}
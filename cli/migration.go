@@ -6,6 +6,7 @@ import (
 	"github.com/brietsparks/xcrud/data"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/urfave/cli"
+	"strconv"
 )
 
 // NewMigrateCommand returns a migration command tree that can be used by a urfave/cli instance
@@ -52,6 +53,20 @@ func NewMigrateCommand(name string, chVars chan data.Vars) cli.Command {
 					return mig.Down()
 				},
 			},
+			{
+				Name:      "force",
+				Usage:     "set the migration version without running migrations, such as to clear the dirty state of a failed migration",
+				ArgsUsage: "<version>",
+				Action: func(c *cli.Context) error {
+					version, err := strconv.Atoi(c.Args().First())
+
+					if err != nil {
+						return fmt.Errorf("invalid version %q", c.Args().First())
+					}
+
+					return mig.Force(version)
+				},
+			},
 		},
 	}
 }
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"encoding/json"
+	"github.com/brietsparks/xcrud/api"
+	"github.com/urfave/cli"
+	"os"
+)
+
+// NewOpenAPICommand returns a command that prints the OpenAPI document of the REST API
+func NewOpenAPICommand(name string) cli.Command {
+	return cli.Command{
+		Name:  name,
+		Usage: "print the OpenAPI document of the REST API served by serve",
+		Action: func(ctx *cli.Context) error {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+
+			return enc.Encode(api.OpenAPI())
+		},
+	}
+}
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+// OutputFormats are the formats a Formatter can render
+var OutputFormats = []string{"json", "pretty", "yaml", "table", "csv", "template"}
+
+// Formatter renders single records, lists of records and pages of records in an output format
+type Formatter struct {
+	format string
+	tmpl   *template.Template
+}
+
+// NewFormatter creates a Formatter for one of the OutputFormats.
+// The "template" format renders values with tmpl, a Go text/template
+func NewFormatter(format string, tmpl string) (*Formatter, error) {
+	f := &Formatter{format: format}
+
+	switch format {
+	case "json", "pretty", "yaml", "table", "csv":
+	case "template":
+		t, err := template.New("output").Parse(tmpl)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse output template: %w", err)
+		}
+
+		f.tmpl = t
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, expected one of %s", format, strings.Join(OutputFormats, ", "))
+	}
+
+	return f, nil
+}
+
+// Print writes a value to stdout
+func (f *Formatter) Print(v interface{}) error {
+	return f.Fprint(os.Stdout, v)
+}
+
+// Fprint writes a value to w
+func (f *Formatter) Fprint(w io.Writer, v interface{}) error {
+	switch f.format {
+	case "pretty":
+		j, err := json.MarshalIndent(v, "", "  ")
+
+		if err != nil {
+			return fmt.Errorf("failed to convert data to json: %w", err)
+		}
+
+		_, err = fmt.Fprintln(w, string(j))
+		return err
+	case "yaml":
+		return printYaml(w, v)
+	case "table":
+		return printTable(w, v)
+	case "csv":
+		return printCsv(w, v)
+	case "template":
+		return printTemplate(w, f.tmpl, v)
+	}
+
+	j, err := json.Marshal(v)
+
+	if err != nil {
+		return fmt.Errorf("failed to convert data to json: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(j))
+	return err
+}
+
+// printYaml renders the json representation of a value as yaml, so that keys match the json output
+func printYaml(w io.Writer, v interface{}) error {
+	j, err := json.Marshal(v)
+
+	if err != nil {
+		return fmt.Errorf("failed to convert data to json: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.UseNumber()
+	doc, err := decodeOrdered(dec)
+
+	if err != nil {
+		return fmt.Errorf("failed to convert data to yaml: %w", err)
+	}
+
+	y, err := yaml.Marshal(doc)
+
+	if err != nil {
+		return fmt.Errorf("failed to convert data to yaml: %w", err)
+	}
+
+	_, err = w.Write(y)
+	return err
+}
+
+// decodeOrdered decodes the next json value, keeping the order of object keys by decoding objects into yaml.MapSlice
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := yaml.MapSlice{}
+
+		for dec.More() {
+			key, err := dec.Token()
+
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeOrdered(dec)
+
+			if err != nil {
+				return nil, err
+			}
+
+			obj = append(obj, yaml.MapItem{Key: key, Value: value})
+		}
+
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+
+			if err != nil {
+				return nil, err
+			}
+
+			arr = append(arr, value)
+		}
+
+		_, err = dec.Token()
+		return arr, err
+	}
+
+	if n, ok := tok.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+
+		return n.Float64()
+	}
+
+	return tok, nil
+}
+
+func printTable(w io.Writer, v interface{}) error {
+	fields, rows, meta, err := tabulate(v)
+
+	if err != nil || fields == nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, strings.ToUpper(strings.Join(fieldNames(fields), "\t")))
+
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, m := range meta {
+		if _, err := fmt.Fprintf(w, "\n%s: %s", m[0], m[1]); err != nil {
+			return err
+		}
+	}
+
+	if len(meta) > 0 {
+		_, err = fmt.Fprintln(w)
+	}
+
+	return err
+}
+
+func printCsv(w io.Writer, v interface{}) error {
+	fields, rows, _, err := tabulate(v)
+
+	if err != nil || fields == nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write(fieldNames(fields))
+	_ = cw.WriteAll(rows)
+
+	return cw.Error()
+}
+
+func printTemplate(w io.Writer, tmpl *template.Template, v interface{}) error {
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, v); err != nil {
+		return fmt.Errorf("failed to execute output template: %w", err)
+	}
+
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteString("\n")
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// tabulate converts a value into rows of cells, with one column per field of its records.
+// A value is either a record, a slice of records, or a page: a struct with one slice of records,
+// whose other non-empty fields are returned as name-value pairs in meta
+func tabulate(v interface{}) ([]field, [][]string, [][2]string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+
+	if !rv.IsValid() {
+		return nil, nil, nil, nil
+	}
+
+	var meta [][2]string
+
+	if rv.Kind() == reflect.Struct {
+		if list, ok := pageRecords(rv); ok {
+			for _, f := range jsonFields(rv.Type()) {
+				fv := rv.FieldByIndex(f.index)
+
+				if fv.IsZero() || isRecords(fv) {
+					continue
+				}
+
+				s, err := formatValue(fv)
+
+				if err != nil {
+					return nil, nil, nil, err
+				}
+
+				meta = append(meta, [2]string{f.name, s})
+			}
+
+			rv = list
+		}
+	}
+
+	var records []reflect.Value
+
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			records = append(records, reflect.Indirect(rv.Index(i)))
+		}
+	} else {
+		records = []reflect.Value{rv}
+	}
+
+	t := rv.Type()
+
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, nil, nil, fmt.Errorf("cannot render %s as rows", t)
+	}
+
+	fields := jsonFields(t)
+	rows := make([][]string, len(records))
+
+	for i, record := range records {
+		rows[i] = make([]string, len(fields))
+
+		for j, f := range fields {
+			s, err := formatValue(record.FieldByIndex(f.index))
+
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			rows[i][j] = s
+		}
+	}
+
+	return fields, rows, meta, nil
+}
+
+// pageRecords returns the records of a page, which is a struct with exactly one slice of records
+func pageRecords(v reflect.Value) (reflect.Value, bool) {
+	var list reflect.Value
+	count := 0
+
+	for i := 0; i < v.NumField(); i++ {
+		if isRecords(v.Field(i)) {
+			list = v.Field(i)
+			count++
+		}
+	}
+
+	return list, count == 1
+}
+
+func isRecords(v reflect.Value) bool {
+	if v.Kind() != reflect.Slice {
+		return false
+	}
+
+	t := v.Type().Elem()
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct
+}
@@ -2,18 +2,21 @@ package cli
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/brietsparks/xcrud/data"
 	"github.com/urfave/cli"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // NewResourcesCommand returns a resources command tree that can be used by a urfave/cli instance
 func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.Command {
 	var vars data.Vars
 	var store *data.Store
+	var output *Formatter
 
 	// flag values
 	var userId int64
@@ -21,6 +24,7 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 	var firstName string
 	var lastName string
 	var groupName string
+	var role string
 
 	return cli.Command{
 		Name:  name,
@@ -28,6 +32,14 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 		Before: func(context *cli.Context) error {
 			vars = <-chVars
 
+			f, err := NewFormatter(context.GlobalString("output"), context.GlobalString("template"))
+
+			if err != nil {
+				return err
+			}
+
+			output = f
+
 			url := data.MakeUrl(vars)
 			db, err := sql.Open("postgres", url)
 
@@ -47,22 +59,38 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 		Subcommands: []cli.Command{
 			{
 				Name: "user:get",
+				Usage: "get a user by Id, or by --Email or --Username",
+				Flags: []cli.Flag{
+					cli.StringFlag{Name: "Email"},
+					cli.StringFlag{Name: "Username"},
+				},
 				Action: func(ctx *cli.Context) error {
-					id, err := getIdArg(ctx)
+					var user *data.User
+					var err error
 
-					if err != nil {
-						logger.Error(errors.Unwrap(err))
-						return err
+					switch {
+					case ctx.IsSet("Email"):
+						user, err = store.Strict().GetUserByEmail(ctx.String("Email"))
+					case ctx.IsSet("Username"):
+						user, err = store.Strict().GetUserByUsername(ctx.String("Username"))
+					default:
+						var id int64
+						id, err = getIdArg(ctx)
+
+						if err != nil {
+							logger.Error(errors.Unwrap(err))
+							return err
+						}
+
+						user, err = store.Strict().GetUserById(id)
 					}
 
-					user, err := store.GetUserById(id)
-
 					if err != nil {
 						logger.Error(errors.Unwrap(err))
 						return err
 					}
 
-					return Printed(user)
+					return output.Print(user)
 				},
 			},
 			{
@@ -70,19 +98,34 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 				Flags: []cli.Flag{
 					cli.StringFlag{Name: "FirstName", Destination: &firstName, Required: true},
 					cli.StringFlag{Name: "LastName", Destination: &lastName, Required: true},
+					cli.StringFlag{Name: "Email"},
+					cli.StringFlag{Name: "Username"},
+					cli.BoolFlag{Name: "upsert", Usage: "update the user with the same email instead, if there is one"},
 				},
 				Action: func(ctx *cli.Context) error {
-					user, err := store.CreateUser(&data.User{
+					u := &data.User{
 						FirstName: firstName,
 						LastName: lastName,
-					})
+						Email: optionalString(ctx, "Email"),
+						Username: optionalString(ctx, "Username"),
+					}
+
+					var user *data.User
+					var err error
+
+					if ctx.Bool("upsert") {
+						user, err = store.UpsertUser(u)
+					} else {
+						user, err = store.CreateUser(u)
+					}
 
 					if err != nil {
 						logger.Error(errors.Unwrap(err))
+						printViolations(output, err)
 						return err
 					}
 
-					return Printed(user)
+					return output.Print(user)
 				},
 			},
 			{
@@ -90,6 +133,9 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 				Flags: []cli.Flag{
 					cli.StringFlag{Name: "FirstName", Destination: &firstName},
 					cli.StringFlag{Name: "LastName", Destination: &lastName},
+					cli.StringFlag{Name: "Email", Usage: "an empty value removes the email"},
+					cli.StringFlag{Name: "Username", Usage: "an empty value removes the username"},
+					cli.Int64Flag{Name: "if-version", Usage: "only update the user if it is still at this version"},
 				},
 				Action: func(ctx *cli.Context) error {
 					id, err := getIdArg(ctx)
@@ -100,17 +146,30 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 					}
 
 					fields := getPassedFlagNames(ctx)
-
-					err = store.UpdateUser(id, &data.User{
+					u := &data.User{
 						FirstName: firstName,
 						LastName: lastName,
-					}, fields...)
+						Email: optionalString(ctx, "Email"),
+						Username: optionalString(ctx, "Username"),
+					}
+
+					if ctx.IsSet("if-version") {
+						err = store.UpdateUserIfVersion(id, ctx.Int64("if-version"), u, fields...)
+					} else {
+						err = store.UpdateUser(id, u, fields...)
+					}
+
+					printViolations(output, err)
 
 					return err
 				},
 			},
 			{
 				Name: "user:delete",
+				Flags: []cli.Flag{
+					cli.BoolFlag{Name: "cascade", Usage: "also remove the user's group memberships"},
+					cli.BoolFlag{Name: "restrict", Usage: "fail if the user has group memberships"},
+				},
 				Action: func(ctx *cli.Context) error {
 					id, err := getIdArg(ctx)
 
@@ -119,15 +178,92 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 						return err
 					}
 
-					return store.DeleteUser(id)
+					return store.DeleteUser(id, getDeletePolicy(ctx))
 				},
 			},
 			{
-				Name: "users:get",
+				Name: "user:restore",
+				Usage: "restore a deleted user",
+				Action: func(ctx *cli.Context) error {
+					id, err := getIdArg(ctx)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+						return err
+					}
+
+					return store.RestoreUser(id)
+				},
+			},
+			{
+				Name: "user:purge",
+				Usage: "permanently delete a user",
 				Flags: []cli.Flag{
-					cli.Int64Flag{Name: "GroupId", Destination: &groupId, Required: true},
+					cli.BoolFlag{Name: "cascade", Usage: "also remove the user's group memberships"},
+				},
+				Action: func(ctx *cli.Context) error {
+					id, err := getIdArg(ctx)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+						return err
+					}
+
+					return store.PurgeUser(id, getDeletePolicy(ctx))
+				},
+			},
+			{
+				Name: "users:list",
+				Flags: listFlags,
+				Action: func(ctx *cli.Context) error {
+					opts, err := getListOptions(ctx)
+
+					if err != nil {
+						logger.Error(err)
+						return err
+					}
+
+					users, err := store.ListUsers(opts)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+						return err
+					}
+
+					return output.Print(users)
 				},
+			},
+			{
+				Name: "users:get",
+				Flags: append([]cli.Flag{
+					cli.Int64Flag{Name: "GroupId", Destination: &groupId, Required: true},
+					cli.BoolFlag{Name: "descendants", Usage: "also get the users of the group's descendants"},
+				}, listFlags...),
 				Action: func(ctx *cli.Context) error {
+					if isListRequested(ctx) || ctx.Bool("descendants") {
+						opts, err := getListOptions(ctx)
+
+						if err != nil {
+							logger.Error(err)
+							return err
+						}
+
+						var users *data.UserList
+
+						if ctx.Bool("descendants") {
+							users, err = store.ListUsersInGroupTree(groupId, opts)
+						} else {
+							users, err = store.ListUsersByGroupId(groupId, opts)
+						}
+
+						if err != nil {
+							logger.Error(errors.Unwrap(err))
+							return err
+						}
+
+						return output.Print(users)
+					}
+
 					var users []data.User
 					var err error
 
@@ -139,7 +275,7 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 						return err
 					}
 
-					return Printed(users)
+					return output.Print(users)
 				},
 			},
 			{
@@ -152,36 +288,54 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 						return err
 					}
 
-					group, err := store.GetGroupById(id)
+					group, err := store.Strict().GetGroupById(id)
 
 					if err != nil {
 						logger.Error(errors.Unwrap(err))
 						return err
 					}
 
-					return Printed(group)
+					return output.Print(group)
 				},
 			},
 			{
 				Name: "group:create",
 				Flags: []cli.Flag{
 					cli.StringFlag{Name: "Name", Destination: &groupName, Required: true},
+					cli.Int64Flag{Name: "ParentId", Usage: "the group to create the group under"},
+					cli.BoolFlag{Name: "upsert", Usage: "update the group with the same name and parent instead, if there is one"},
 				},
 				Action: func(ctx *cli.Context) error {
-					group, err := store.CreateGroup(&data.Group{Name: groupName})
+					g := &data.Group{Name: groupName}
+
+					if ctx.IsSet("ParentId") {
+						parentId := ctx.Int64("ParentId")
+						g.ParentId = &parentId
+					}
+
+					var group *data.Group
+					var err error
+
+					if ctx.Bool("upsert") {
+						group, err = store.UpsertGroup(g)
+					} else {
+						group, err = store.CreateGroup(g)
+					}
 
 					if err != nil {
 						logger.Error(errors.Unwrap(err))
+						printViolations(output, err)
 						return err
 					}
 
-					return Printed(group)
+					return output.Print(group)
 				},
 			},
 			{
 				Name: "group:update",
 				Flags: []cli.Flag{
 					cli.StringFlag{Name: "Name", Destination: &groupName},
+					cli.Int64Flag{Name: "if-version", Usage: "only update the group if it is still at this version"},
 				},
 				Action: func(ctx *cli.Context) error {
 					id, err := getIdArg(ctx)
@@ -192,14 +346,55 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 					}
 
 					fields := getPassedFlagNames(ctx)
+					g := &data.Group{Name: groupName}
+
+					if ctx.IsSet("if-version") {
+						err = store.UpdateGroupIfVersion(id, ctx.Int64("if-version"), g, fields...)
+					} else {
+						err = store.UpdateGroup(id, g, fields...)
+					}
 
-					err = store.UpdateGroup(id, &data.Group{Name: groupName}, fields...)
+					printViolations(output, err)
+
+					return err
+				},
+			},
+			{
+				Name: "group:move",
+				Usage: "move a group and its descendants under another group, or to the root without --ParentId",
+				Flags: []cli.Flag{
+					cli.Int64Flag{Name: "ParentId", Usage: "the group to move the group under"},
+				},
+				Action: func(ctx *cli.Context) error {
+					id, err := getIdArg(ctx)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+						return err
+					}
+
+					var parentId *int64
+
+					if ctx.IsSet("ParentId") {
+						p := ctx.Int64("ParentId")
+						parentId = &p
+					}
+
+					err = store.MoveGroup(id, parentId)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+					}
 
 					return err
 				},
 			},
 			{
 				Name: "group:delete",
+				Flags: []cli.Flag{
+					cli.BoolFlag{Name: "cascade", Usage: "also remove the group's group memberships"},
+					cli.BoolFlag{Name: "restrict", Usage: "fail if the group has group memberships"},
+				},
 				Action: func(ctx *cli.Context) error {
 					id, err := getIdArg(ctx)
 
@@ -208,15 +403,92 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 						return err
 					}
 
-					return store.DeleteGroup(id)
+					return store.DeleteGroup(id, getDeletePolicy(ctx))
 				},
 			},
 			{
-				Name: "groups:get",
+				Name: "group:restore",
+				Usage: "restore a deleted group",
+				Action: func(ctx *cli.Context) error {
+					id, err := getIdArg(ctx)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+						return err
+					}
+
+					return store.RestoreGroup(id)
+				},
+			},
+			{
+				Name: "group:purge",
+				Usage: "permanently delete a group",
 				Flags: []cli.Flag{
-					cli.Int64Flag{Name: "UserId", Destination: &userId, Required: true},
+					cli.BoolFlag{Name: "cascade", Usage: "also remove the group's group memberships"},
+				},
+				Action: func(ctx *cli.Context) error {
+					id, err := getIdArg(ctx)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+						return err
+					}
+
+					return store.PurgeGroup(id, getDeletePolicy(ctx))
 				},
+			},
+			{
+				Name: "groups:list",
+				Flags: listFlags,
 				Action: func(ctx *cli.Context) error {
+					opts, err := getListOptions(ctx)
+
+					if err != nil {
+						logger.Error(err)
+						return err
+					}
+
+					groups, err := store.ListGroups(opts)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+						return err
+					}
+
+					return output.Print(groups)
+				},
+			},
+			{
+				Name: "groups:get",
+				Flags: append([]cli.Flag{
+					cli.Int64Flag{Name: "UserId", Destination: &userId, Required: true},
+					cli.BoolFlag{Name: "effective", Usage: "also get the ancestors of the user's groups"},
+				}, listFlags...),
+				Action: func(ctx *cli.Context) error {
+					if isListRequested(ctx) || ctx.Bool("effective") {
+						opts, err := getListOptions(ctx)
+
+						if err != nil {
+							logger.Error(err)
+							return err
+						}
+
+						var groups *data.GroupList
+
+						if ctx.Bool("effective") {
+							groups, err = store.ListEffectiveGroupsByUserId(userId, opts)
+						} else {
+							groups, err = store.ListGroupsByUserId(userId, opts)
+						}
+
+						if err != nil {
+							logger.Error(errors.Unwrap(err))
+							return err
+						}
+
+						return output.Print(groups)
+					}
+
 					var groups []data.Group
 					var err error
 
@@ -229,7 +501,69 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 						return err
 					}
 
-					return Printed(groups)
+					return output.Print(groups)
+				},
+			},
+			{
+				Name: "import",
+				Usage: "create resources from a file",
+				Flags: []cli.Flag{
+					cli.StringFlag{Name: "format", Value: "json", Usage: "csv, json or ndjson"},
+					cli.StringFlag{Name: "file", Required: true},
+					cli.StringFlag{Name: "type", Required: true, Usage: "user, group or group_user"},
+					cli.BoolFlag{Name: "dry-run", Usage: "report what would be imported without writing it"},
+					cli.BoolFlag{Name: "upsert", Usage: "update existing users by email, groups by name and parent, and memberships"},
+				},
+				Action: func(ctx *cli.Context) error {
+					file, err := os.Open(ctx.String("file"))
+
+					if err != nil {
+						logger.Error(err)
+						return err
+					}
+
+					defer file.Close()
+
+					summary, err := ImportRecords(store, file, ctx.String("format"), ctx.String("type"), ctx.Bool("dry-run"), ctx.Bool("upsert"))
+
+					if err != nil {
+						logger.Error(err)
+						return err
+					}
+
+					return output.Print(summary)
+				},
+			},
+			{
+				Name: "export",
+				Usage: "write resources to a file or stdout",
+				Flags: []cli.Flag{
+					cli.StringFlag{Name: "format", Value: "json", Usage: "csv, json or ndjson"},
+					cli.StringFlag{Name: "file", Usage: "defaults to stdout"},
+					cli.StringFlag{Name: "type", Required: true, Usage: "user, group or group_user"},
+				},
+				Action: func(ctx *cli.Context) error {
+					out := os.Stdout
+
+					if ctx.IsSet("file") {
+						file, err := os.Create(ctx.String("file"))
+
+						if err != nil {
+							logger.Error(err)
+							return err
+						}
+
+						defer file.Close()
+						out = file
+					}
+
+					err := ExportRecords(store, out, ctx.String("format"), ctx.String("type"))
+
+					if err != nil {
+						logger.Error(err)
+					}
+
+					return err
 				},
 			},
 			{
@@ -237,9 +571,21 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 				Flags: []cli.Flag{
 					cli.Int64Flag{Name: "GroupId", Destination: &groupId, Required: true},
 					cli.Int64Flag{Name: "UserId", Destination: &userId, Required: true},
+					cli.StringFlag{Name: "Role", Destination: &role, Value: string(data.RoleMember), Usage: "role of the user in the group: owner, admin or member"},
+					cli.BoolFlag{Name: "upsert", Usage: "succeed if the user is already in the group, changing its role if --Role is passed"},
 				},
 				Action: func(ctx *cli.Context) error {
-					err := store.LinkGroupToUser(groupId, userId)
+					var err error
+
+					switch {
+					case ctx.Bool("upsert") && ctx.IsSet("Role"):
+						err = store.EnsureLinked(groupId, userId, data.Role(role))
+					case ctx.Bool("upsert"):
+						err = store.EnsureLinked(groupId, userId)
+					default:
+						err = store.LinkGroupToUser(groupId, userId, data.Role(role))
+					}
+					printViolations(output, err)
 
 					if err != nil {
 						logger.Error(errors.Unwrap(err))
@@ -248,6 +594,41 @@ func NewResourcesCommand(name string, chVars chan data.Vars, logger Logger) cli.
 					return err
 				},
 			},
+			{
+				Name: "group:set-role",
+				Flags: []cli.Flag{
+					cli.Int64Flag{Name: "GroupId", Destination: &groupId, Required: true},
+					cli.Int64Flag{Name: "UserId", Destination: &userId, Required: true},
+					cli.StringFlag{Name: "Role", Destination: &role, Required: true, Usage: "role of the user in the group: owner, admin or member"},
+				},
+				Action: func(ctx *cli.Context) error {
+					err := store.SetMemberRole(groupId, userId, data.Role(role))
+					printViolations(output, err)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+					}
+
+					return err
+				},
+			},
+			{
+				Name: "group:get-user",
+				Flags: []cli.Flag{
+					cli.Int64Flag{Name: "GroupId", Destination: &groupId, Required: true},
+					cli.Int64Flag{Name: "UserId", Destination: &userId, Required: true},
+				},
+				Action: func(ctx *cli.Context) error {
+					membership, err := store.Strict().GetMembership(groupId, userId)
+
+					if err != nil {
+						logger.Error(errors.Unwrap(err))
+						return err
+					}
+
+					return output.Print(membership)
+				},
+			},
 			{
 				Name: "group:remove-user",
 				Flags: []cli.Flag{
@@ -284,6 +665,75 @@ func getIdArg(ctx *cli.Context) (int64, error) {
 	return i, nil
 }
 
+// getDeletePolicy returns the delete policy selected by the --cascade and --restrict flags
+func getDeletePolicy(ctx *cli.Context) data.DeletePolicy {
+	if ctx.Bool("cascade") {
+		return data.DeleteCascade
+	}
+
+	if ctx.Bool("restrict") {
+		return data.DeleteRestrict
+	}
+
+	return data.DeleteKeep
+}
+
+var listFlags = []cli.Flag{
+	cli.IntFlag{Name: "limit", Usage: "maximum number of records in the page"},
+	cli.StringFlag{Name: "cursor", Usage: "cursor of the page to get, as printed with the previous page"},
+	cli.StringSliceFlag{Name: "where", Usage: "filter such as lastName~=pee, using one of the operators = != < <= > >= ^= ~="},
+	cli.StringFlag{Name: "sort", Usage: "comma separated fields to sort by, such as -lastName,firstName"},
+	cli.BoolFlag{Name: "count", Usage: "include the total number of records across all pages"},
+	cli.StringFlag{Name: "since", Usage: "only records changed, or memberships linked, at or after an RFC 3339 time such as 2019-11-01T00:00:00Z"},
+}
+
+// isListRequested reports whether any of the listFlags were passed
+func isListRequested(ctx *cli.Context) bool {
+	for _, flag := range listFlags {
+		if ctx.IsSet(flag.GetName()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getListOptions(ctx *cli.Context) (data.ListOptions, error) {
+	opts := data.ListOptions{
+		Limit: ctx.Int("limit"),
+		Cursor: ctx.String("cursor"),
+		Count: ctx.Bool("count"),
+	}
+
+	for _, expr := range ctx.StringSlice("where") {
+		filter, err := data.ParseFilter(expr)
+
+		if err != nil {
+			return opts, err
+		}
+
+		opts.Filters = append(opts.Filters, filter)
+	}
+
+	if since := ctx.String("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+
+		if err != nil {
+			return opts, fmt.Errorf("invalid since time %q", since)
+		}
+
+		opts.Since = t
+	}
+
+	if sort := ctx.String("sort"); sort != "" {
+		for _, expr := range strings.Split(sort, ",") {
+			opts.Sort = append(opts.Sort, data.ParseSort(expr))
+		}
+	}
+
+	return opts, nil
+}
+
 func getPassedFlagNames(ctx *cli.Context) []string {
  	fields := make([]string, 0)
 
@@ -296,14 +746,25 @@ func getPassedFlagNames(ctx *cli.Context) []string {
  	return fields
 }
 
-func Printed(v interface {}) error {
-	j, err := json.Marshal(v)
-
-	if err != nil {
-		return fmt.Errorf("failed to convert data to json: %w", err)
+// optionalString returns the value of a string flag, or nil if the flag is not set or empty
+func optionalString(ctx *cli.Context, name string) *string {
+	if s := ctx.String(name); s != "" {
+		return &s
 	}
 
-	fmt.Println(string(j))
-
 	return nil
 }
+
+// printViolations prints the violations of a validation error to stderr in the output format, one per field
+func printViolations(output *Formatter, err error) {
+	var validationErr *data.ValidationError
+
+	if errors.As(err, &validationErr) {
+		_ = output.Fprint(os.Stderr, validationErr)
+	}
+}
+
+// Printed prints a value to stdout as compact json
+func Printed(v interface {}) error {
+	return (&Formatter{format: "json"}).Print(v)
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/brietsparks/xcrud/api"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/brietsparks/xcrud/graph"
+	"github.com/brietsparks/xcrud/rpc"
+	"github.com/urfave/cli"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// how long in-flight requests and streams may take to finish when the server is stopped
+const shutdownTimeout = 10 * time.Second
+
+// NewServeCommand returns a command that serves the data resources as a REST API and a GraphQL endpoint over HTTP
+func NewServeCommand(name string, chVars chan data.Vars, logger Logger) cli.Command {
+	return cli.Command{
+		Name:  name,
+		Usage: "serve data resources as a REST API and over GraphQL",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "addr", Value: ":8080", Usage: "the `ADDRESS` to listen on"},
+		},
+		Action: func(ctx *cli.Context) error {
+			vars := <-chVars
+			db, err := sql.Open("postgres", data.MakeUrl(vars))
+
+			if err != nil {
+				return err
+			}
+
+			store, err := data.NewStore(db, 10)
+
+			if err != nil {
+				return err
+			}
+
+			mux := http.NewServeMux()
+			mux.Handle("/", api.NewServer(store))
+			mux.Handle("POST /graphql", graph.NewHandler(store, func() graph.Loader {
+				return store.NewLoader()
+			}))
+
+			server := &http.Server{Addr: ctx.String("addr"), Handler: mux}
+
+			return listenUntilSignal(server.Addr, server.ListenAndServe, server.Shutdown, logger)
+		},
+	}
+}
+
+// NewServeGRPCCommand returns a command that serves the data resources as the gRPC service xcrud.v1.Store
+func NewServeGRPCCommand(name string, chVars chan data.Vars, logger Logger) cli.Command {
+	return cli.Command{
+		Name:  name,
+		Usage: "serve data resources over gRPC",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "addr", Value: ":9090", Usage: "the `ADDRESS` to listen on"},
+		},
+		Action: func(ctx *cli.Context) error {
+			vars := <-chVars
+			db, err := sql.Open("postgres", data.MakeUrl(vars))
+
+			if err != nil {
+				return err
+			}
+
+			store, err := data.NewStore(db, 10)
+
+			if err != nil {
+				return err
+			}
+
+			lis, err := net.Listen("tcp", ctx.String("addr"))
+
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+
+			server := rpc.NewServer(store).Register()
+
+			return listenUntilSignal(lis.Addr().String(), func() error {
+				return server.Serve(lis)
+			}, func(ctx context.Context) error {
+				stopped := make(chan struct{})
+
+				go func() {
+					server.GracefulStop()
+					close(stopped)
+				}()
+
+				select {
+				case <-stopped:
+					return nil
+				case <-ctx.Done():
+					server.Stop()
+					return ctx.Err()
+				}
+			}, logger)
+		},
+	}
+}
+
+// listenUntilSignal serves until serving fails or the process is interrupted, then shuts down gracefully
+func listenUntilSignal(addr string, serve func() error, shutdown func(ctx context.Context) error, logger Logger) error {
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	errs := make(chan error, 1)
+
+	go func() {
+		fmt.Printf("listening on %s\n", addr)
+		errs <- serve()
+	}()
+
+	select {
+	case err := <-errs:
+		logger.Error(err)
+		return err
+	case <-stop.Done():
+	}
+
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+
+	if err := shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"github.com/brietsparks/xcrud/cli"
+	"github.com/brietsparks/xcrud/data"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+)
+
+type TransferTestSuite struct {
+	suite.Suite
+	db    *sql.DB
+	Store *data.Store
+}
+
+var envPath string
+
+func init() {
+	flag.StringVar(&envPath, "env", "", "")
+}
+
+func (s *TransferTestSuite) SetupSuite() {
+	if envPath == "" {
+		s.T().Fatal("missing variable --env <path to .env file>")
+	}
+
+	vars, err := data.LoadEnvVars(envPath)
+
+	if err != nil {
+		s.T().Fatalf("failed to load environment variables: %s", err)
+	}
+
+	s.db, err = sql.Open("postgres", data.MakeUrl(vars))
+
+	if err != nil {
+		s.T().Fatalf("failed to connect to database: %s", err)
+	}
+
+	s.Store, err = data.NewStore(s.db, 10)
+
+	if err != nil {
+		s.T().Fatalf("failed to create store: %s", err)
+	}
+}
+
+func (s *TransferTestSuite) SetupTest() {
+	_, err := s.db.Exec(`truncate table "user", "group", group_user cascade`)
+
+	if err != nil {
+		s.T().Fatalf("failed to clear table: %s", err)
+	}
+}
+
+func (s *TransferTestSuite) TearDownSuite() {
+	s.SetupTest()
+}
+
+// the fourth user has the email of the second, and is in the same batch as the others
+const users = `[
+	{"firstName": "A", "lastName": "B", "email": "a@example.com"},
+	{"firstName": "C", "lastName": "D", "email": "c@example.com"},
+	{"firstName": "E", "lastName": "F"},
+	{"firstName": "G", "lastName": "H", "email": "C@example.com"},
+	{"firstName": "I", "lastName": "J"}
+]`
+
+func (s *TransferTestSuite) TestImportConflictInBatch() {
+	summary, err := cli.ImportRecords(s.Store, strings.NewReader(users), "json", "user", false, false)
+	s.Require().NoError(err)
+
+	s.Assert().Equal(4, summary.Created)
+	s.Assert().Equal(1, summary.Skipped)
+	s.Assert().Equal(0, summary.Failed)
+
+	list, err := s.Store.ListUsers(data.ListOptions{Count: true})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(4), *list.Total)
+}
+
+func (s *TransferTestSuite) TestDryRunConflictInBatch() {
+	summary, err := cli.ImportRecords(s.Store, strings.NewReader(users), "json", "user", true, false)
+	s.Require().NoError(err)
+
+	s.Assert().Equal(4, summary.Created)
+	s.Assert().Equal(1, summary.Skipped)
+	s.Assert().Equal(0, summary.Failed)
+
+	list, err := s.Store.ListUsers(data.ListOptions{Count: true})
+	s.Require().NoError(err)
+	s.Assert().Empty(list.Users)
+}
+
+func (s *TransferTestSuite) TestExportImport() {
+	parent, err := s.Store.CreateGroup(&data.Group{Name: "parent"})
+	s.Require().NoError(err)
+	child, err := s.Store.CreateGroup(&data.Group{Name: "child", ParentId: &parent.Id})
+	s.Require().NoError(err)
+	u, err := s.Store.CreateUser(&data.User{FirstName: "Bo", LastName: "Peep"})
+	s.Require().NoError(err)
+	_, err = s.Store.CreateMembership(&data.Membership{GroupId: child.Id, UserId: u.Id, Attributes: data.Attributes{"title": "lead"}})
+	s.Require().NoError(err)
+
+	exports := map[string]*bytes.Buffer{}
+
+	for _, resourceType := range []string{"user", "group", "group_user"} {
+		exports[resourceType] = &bytes.Buffer{}
+		s.Require().NoError(cli.ExportRecords(s.Store, exports[resourceType], "ndjson", resourceType))
+	}
+
+	s.SetupTest()
+
+	// the imported records keep their ids, so parents and memberships refer to the same rows
+	for _, resourceType := range []string{"user", "group", "group_user"} {
+		summary, err := cli.ImportRecords(s.Store, exports[resourceType], "ndjson", resourceType, false, false)
+		s.Require().NoError(err)
+		s.Assert().Zero(summary.Failed, resourceType)
+	}
+
+	g, err := s.Store.GetGroupById(child.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("child", g.Name)
+	s.Assert().Equal(&parent.Id, g.ParentId)
+
+	m, err := s.Store.GetMembership(child.Id, u.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal(data.Attributes{"title": "lead"}, m.Attributes)
+
+	// the generated ids continue after the imported ones
+	created, err := s.Store.CreateUser(&data.User{FirstName: "Jo", LastName: "Jackson"})
+	s.Require().NoError(err)
+	s.Assert().Greater(created.Id, u.Id)
+}
+
+func TestTransferTestSuite(t *testing.T) {
+	suite.Run(t, new(TransferTestSuite))
+}
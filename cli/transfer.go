@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/brietsparks/xcrud/data"
+	"io"
+)
+
+// ImportSummary reports the outcome of an import
+type ImportSummary struct {
+	Created  int           `json:"created"`
+	Upserted int           `json:"upserted,omitempty"`
+	Skipped  int           `json:"skipped"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors,omitempty"`
+	upsert   bool
+}
+
+// ImportError is the error of a single row of an import
+type ImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// resourceTypes maps the resource types that can be imported and exported to their models
+var resourceTypes = map[string]interface{}{
+	"user":       data.User{},
+	"group":      data.Group{},
+	"group_user": data.Membership{},
+}
+
+const importBatchSize = 500
+
+var errDryRun = errors.New("dry run")
+
+// ImportRecords reads records of a resource type and creates them in batches, or upserts them one by one.
+// Records that conflict with existing ones are skipped without failing the others of their batch.
+// In a dry run each batch is written in a transaction that is rolled back
+func ImportRecords(store *data.Store, r io.Reader, format string, resourceType string, dryRun bool, upsert bool) (*ImportSummary, error) {
+	model, ok := resourceTypes[resourceType]
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported resource type %q", resourceType)
+	}
+
+	summary := &ImportSummary{upsert: upsert}
+	var batch []interface{}
+	var rows []int
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		results := runBatch(store, batch, dryRun, upsert)
+
+		for i, result := range results {
+			summary.add(rows[i], result.Err)
+		}
+
+		batch, rows = nil, nil
+	}
+
+	err := readRecords(format, r, model, func(row int, record interface{}, err error) error {
+		if err != nil {
+			summary.add(row, err)
+			return nil
+		}
+
+		batch = append(batch, record)
+		rows = append(rows, row)
+
+		if len(batch) == importBatchSize {
+			flush()
+		}
+
+		return nil
+	})
+
+	flush()
+
+	return summary, err
+}
+
+func (s *ImportSummary) add(row int, err error) {
+	switch {
+	case err == nil && s.upsert:
+		s.Upserted++
+	case err == nil:
+		s.Created++
+	case errors.Is(err, data.ErrConflict):
+		s.Skipped++
+	default:
+		s.Failed++
+		s.Errors = append(s.Errors, ImportError{Row: row, Error: err.Error()})
+	}
+}
+
+func runBatch(store *data.Store, batch []interface{}, dryRun bool, upsert bool) []data.BulkResult {
+	write := createBatch
+
+	if upsert {
+		write = upsertBatch
+	}
+
+	if !dryRun {
+		return write(store, batch)
+	}
+
+	var results []data.BulkResult
+
+	err := store.WithTx(context.Background(), func(tx *data.Store) error {
+		results = write(tx, batch)
+		return errDryRun
+	})
+
+	// the transaction could not be started
+	if results == nil {
+		results = make([]data.BulkResult, len(batch))
+
+		for i := range results {
+			results[i].Err = err
+		}
+	}
+
+	return results
+}
+
+// createBatch creates a batch of records, which all have the same type
+func createBatch(store *data.Store, batch []interface{}) []data.BulkResult {
+	switch batch[0].(type) {
+	case *data.User:
+		users := make([]*data.User, len(batch))
+
+		for i, record := range batch {
+			users[i] = record.(*data.User)
+		}
+
+		return store.CreateUsers(users)
+	case *data.Group:
+		groups := make([]*data.Group, len(batch))
+
+		for i, record := range batch {
+			groups[i] = record.(*data.Group)
+		}
+
+		return store.CreateGroups(groups)
+	}
+
+	// memberships without attributes are linked per group and role,
+	// while those with attributes are created one by one so that each is written with its attributes
+	type link struct {
+		groupId int64
+		role    data.Role
+	}
+
+	results := make([]data.BulkResult, len(batch))
+	userIds := map[link][]int64{}
+	index := map[link][]int{}
+	var links []link
+
+	for i, record := range batch {
+		m := record.(*data.Membership)
+
+		if m.Attributes != nil {
+			_, results[i].Err = store.CreateMembership(m)
+			results[i].Id = m.UserId
+			continue
+		}
+
+		l := link{m.GroupId, m.Role}
+
+		if _, ok := userIds[l]; !ok {
+			links = append(links, l)
+		}
+
+		userIds[l] = append(userIds[l], m.UserId)
+		index[l] = append(index[l], i)
+	}
+
+	for _, l := range links {
+		for i, result := range store.LinkGroupToUsers(l.groupId, userIds[l], l.role) {
+			results[index[l][i]] = result
+		}
+	}
+
+	return results
+}
+
+// upsertBatch creates or updates each record of a batch: users by email, groups by name and parent,
+// and memberships by group and user
+func upsertBatch(store *data.Store, batch []interface{}) []data.BulkResult {
+	results := make([]data.BulkResult, len(batch))
+
+	for i, record := range batch {
+		switch r := record.(type) {
+		case *data.User:
+			_, results[i].Err = store.UpsertUser(r)
+			results[i].Id = r.Id
+		case *data.Group:
+			_, results[i].Err = store.UpsertGroup(r)
+			results[i].Id = r.Id
+		case *data.Membership:
+			var role []data.Role
+
+			if r.Role != "" {
+				role = append(role, r.Role)
+			}
+
+			results[i].Err = store.WithTx(context.Background(), func(tx *data.Store) error {
+				if err := tx.EnsureLinked(r.GroupId, r.UserId, role...); err != nil || r.Attributes == nil {
+					return err
+				}
+
+				return tx.SetMemberAttributes(r.GroupId, r.UserId, r.Attributes)
+			})
+		}
+	}
+
+	return results
+}
+
+// ExportRecords writes every record of a resource type, fetching them page by page
+func ExportRecords(store *data.Store, w io.Writer, format string, resourceType string) error {
+	model, ok := resourceTypes[resourceType]
+
+	if !ok {
+		return fmt.Errorf("unsupported resource type %q", resourceType)
+	}
+
+	rw, err := newRecordWriter(format, w, model)
+
+	if err != nil {
+		return err
+	}
+
+	opts := data.ListOptions{Limit: data.MaxPageSize}
+
+	for {
+		records, next, err := listPage(store, resourceType, opts)
+
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if err := rw.Write(record); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			break
+		}
+
+		opts.Cursor = next
+	}
+
+	return rw.Close()
+}
+
+func listPage(store *data.Store, resourceType string, opts data.ListOptions) ([]interface{}, string, error) {
+	var records []interface{}
+
+	switch resourceType {
+	case "user":
+		page, err := store.ListUsers(opts)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		for i := range page.Users {
+			records = append(records, &page.Users[i])
+		}
+
+		return records, page.NextCursor, nil
+	case "group":
+		page, err := store.ListGroups(opts)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		for i := range page.Groups {
+			records = append(records, &page.Groups[i])
+		}
+
+		return records, page.NextCursor, nil
+	}
+
+	page, err := store.ListMemberships(opts)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i := range page.Memberships {
+		records = append(records, &page.Memberships[i])
+	}
+
+	return records, page.NextCursor, nil
+}
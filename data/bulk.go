@@ -0,0 +1,286 @@
+package data
+
+import (
+	"context"
+	"github.com/gocraft/dbr/v2"
+)
+
+// BulkResult is the outcome of a single item of a bulk operation.
+// Bulk operations return one result per item, in the order of the items
+type BulkResult struct {
+	Id  int64
+	Err error
+}
+
+// CreateUsers creates users with multi-row inserts.
+// Every user is validated before any is inserted, and invalid users are not inserted.
+// The ID of each created user is set on the user and its result, and its timestamps on the user.
+// A user whose ID is set keeps it, such as when users are copied from another database
+func (s *Store) CreateUsers(users []*User) []BulkResult {
+	return s.CreateUsersContext(context.Background(), users)
+}
+
+// CreateUsersContext creates users with multi-row inserts, aborting if ctx is done
+func (s *Store) CreateUsersContext(ctx context.Context, users []*User) []BulkResult {
+	results := make([]BulkResult, len(users))
+	var records []interface{}
+	var index []int
+
+	for i, u := range users {
+		if err := s.validate.Struct(u); err != nil {
+			results[i].Err = NewError(err)
+			continue
+		}
+
+		records = append(records, u)
+		index = append(index, i)
+	}
+
+	for i, result := range s.createMany(ctx, userResource, records) {
+		results[index[i]] = result
+
+		if result.Err == nil {
+			users[index[i]].Id = result.Id
+		}
+	}
+
+	return results
+}
+
+// UpdateUsers updates existing users, identified by their Id, with batched updates.
+// The variadic "fields" arg should contain the field names that should be updated
+func (s *Store) UpdateUsers(users []*User, fields ...string) []BulkResult {
+	return s.UpdateUsersContext(context.Background(), users, fields...)
+}
+
+// UpdateUsersContext updates existing users with batched updates, aborting if ctx is done
+func (s *Store) UpdateUsersContext(ctx context.Context, users []*User, fields ...string) []BulkResult {
+	results := make([]BulkResult, len(users))
+	var ids []int64
+	var rows [][]set
+	var index []int
+
+	for i, u := range users {
+		results[i].Id = u.Id
+
+		if err := s.validate.StructPartial(u, fields...); err != nil {
+			results[i].Err = NewError(err)
+			continue
+		}
+
+		ids = append(ids, u.Id)
+		rows = append(rows, userResource.sets(u))
+		index = append(index, i)
+	}
+
+	for i, result := range s.updateMany(ctx, "user", ids, fields, rows) {
+		results[index[i]] = result
+	}
+
+	return results
+}
+
+// DeleteUsers soft-deletes users with batched updates
+func (s *Store) DeleteUsers(ids []int64) []BulkResult {
+	return s.DeleteUsersContext(context.Background(), ids)
+}
+
+// DeleteUsersContext soft-deletes users with batched updates, aborting if ctx is done
+func (s *Store) DeleteUsersContext(ctx context.Context, ids []int64) []BulkResult {
+	return s.deleteMany(ctx, "user", ids)
+}
+
+// CreateGroups creates groups with multi-row inserts.
+// Every group is validated before any is inserted, and invalid groups are not inserted.
+// The ID of each created group is set on the group and its result, and its timestamps on the group.
+// A group whose ID is set keeps it, so that the parents of groups copied from another database still refer to them
+func (s *Store) CreateGroups(groups []*Group) []BulkResult {
+	return s.CreateGroupsContext(context.Background(), groups)
+}
+
+// CreateGroupsContext creates groups with multi-row inserts, aborting if ctx is done
+func (s *Store) CreateGroupsContext(ctx context.Context, groups []*Group) []BulkResult {
+	results := make([]BulkResult, len(groups))
+	var records []interface{}
+	var index []int
+
+	for i, g := range groups {
+		if err := s.validate.Struct(g); err != nil {
+			results[i].Err = NewError(err)
+			continue
+		}
+
+		records = append(records, g)
+		index = append(index, i)
+	}
+
+	for i, result := range s.createMany(ctx, groupResource, records) {
+		results[index[i]] = result
+
+		if result.Err == nil {
+			groups[index[i]].Id = result.Id
+		}
+	}
+
+	return results
+}
+
+// UpdateGroups updates existing groups, identified by their Id, with batched updates.
+// The variadic "fields" arg should contain the field names that should be updated
+func (s *Store) UpdateGroups(groups []*Group, fields ...string) []BulkResult {
+	return s.UpdateGroupsContext(context.Background(), groups, fields...)
+}
+
+// UpdateGroupsContext updates existing groups with batched updates, aborting if ctx is done
+func (s *Store) UpdateGroupsContext(ctx context.Context, groups []*Group, fields ...string) []BulkResult {
+	results := make([]BulkResult, len(groups))
+	var ids []int64
+	var rows [][]set
+	var index []int
+
+	for i, g := range groups {
+		results[i].Id = g.Id
+
+		if err := s.validate.StructPartial(g, fields...); err != nil {
+			results[i].Err = NewError(err)
+			continue
+		}
+
+		ids = append(ids, g.Id)
+		rows = append(rows, groupResource.sets(g))
+		index = append(index, i)
+	}
+
+	for i, result := range s.updateMany(ctx, "group", ids, fields, rows) {
+		results[index[i]] = result
+	}
+
+	return results
+}
+
+// DeleteGroups soft-deletes groups with batched updates
+func (s *Store) DeleteGroups(ids []int64) []BulkResult {
+	return s.DeleteGroupsContext(context.Background(), ids)
+}
+
+// DeleteGroupsContext soft-deletes groups with batched updates, aborting if ctx is done
+func (s *Store) DeleteGroupsContext(ctx context.Context, ids []int64) []BulkResult {
+	return s.deleteMany(ctx, "group", ids)
+}
+
+// LinkGroupToUsers links a group to many users in a single transaction.
+// Users that do not exist or are already linked to the group are reported in their results, and do not prevent the others from being linked.
+// The optional role is the role of every linked user in the group, and defaults to RoleMember
+func (s *Store) LinkGroupToUsers(groupId int64, userIds []int64, role ...Role) []BulkResult {
+	return s.LinkGroupToUsersContext(context.Background(), groupId, userIds, role...)
+}
+
+// LinkGroupToUsersContext links a group to many users in a single transaction, aborting if ctx is done
+func (s *Store) LinkGroupToUsersContext(ctx context.Context, groupId int64, userIds []int64, role ...Role) []BulkResult {
+	results := make([]BulkResult, len(userIds))
+
+	if len(userIds) == 0 {
+		return results
+	}
+
+	r := memberRole(role)
+	err := s.validateRole(r)
+
+	if err != nil {
+		for i := range results {
+			results[i] = BulkResult{Id: userIds[i], Err: err}
+		}
+
+		return results
+	}
+
+	err = s.WithTx(ctx, func(tx *Store) error {
+		var groupCount int
+		var existing []int64
+
+		err := tx.db.
+			Select("count(*)").
+			From(quotes("group")).
+			Where("id = ? and deleted_at is null", groupId).
+			LoadOneContext(ctx, &groupCount)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.db.
+			Select("id").
+			From(quotes("user")).
+			Where("id in ? and deleted_at is null", userIds).
+			LoadContext(ctx, &existing)
+
+		if err != nil {
+			return err
+		}
+
+		exists := map[int64]bool{}
+		for _, id := range existing {
+			exists[id] = groupCount > 0
+		}
+
+		var toLink []int64
+		var index []int
+		seen := map[int64]bool{}
+
+		for i, id := range userIds {
+			results[i].Id = id
+
+			switch {
+			case !exists[id]:
+				results[i].Err = errGroupOrUserDNE
+			case seen[id]:
+				results[i].Err = errGroupUserAlreadyLinked
+			default:
+				seen[id] = true
+				toLink = append(toLink, id)
+				index = append(index, i)
+			}
+		}
+
+		for start := 0; start < len(toLink); start += bulkBatchSize {
+			end := batchEnd(start, len(toLink))
+			insert := dbr.InsertInto("group_user").Columns("group_id", "user_id", "role")
+
+			for _, id := range toLink[start:end] {
+				insert.Values(groupId, id, r)
+			}
+
+			var linked []int64
+			_, err := tx.db.
+				SelectBySql("? on conflict do nothing returning user_id", insert).
+				LoadContext(ctx, &linked)
+
+			if err != nil {
+				return err
+			}
+
+			isLinked := map[int64]bool{}
+			for _, id := range linked {
+				isLinked[id] = true
+			}
+
+			// rows skipped by the conflict clause were linked before this call
+			for j := start; j < end; j++ {
+				if !isLinked[toLink[j]] {
+					results[index[j]].Err = errGroupUserAlreadyLinked
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		for i := range results {
+			results[i].Id = userIds[i]
+			results[i].Err = NewError(err)
+		}
+	}
+
+	return results
+}
@@ -1,8 +1,44 @@
 package data
 
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"gopkg.in/go-playground/validator.v9"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// sentinel errors that classify the errors returned by the store, for use with errors.Is
+var (
+	// ErrNotFound is returned when a resource does not exist
+	ErrNotFound = errors.New(ErrResourceDNE)
+
+	// ErrConflict matches errors caused by the current state of a resource,
+	// such as a duplicate membership, a changed version or existing memberships
+	ErrConflict = errors.New(ErrResourceConflict)
+
+	// ErrForeignKey matches errors caused by a reference to a resource that does not exist
+	ErrForeignKey = errors.New(ErrReferenceDNE)
+
+	// ErrConnection matches errors caused by the database being unreachable
+	ErrConnection = errors.New(ErrConnectionFailed)
+
+	// ErrInvalidOptions matches errors caused by invalid list options, such as an unknown field, a malformed filter or a stale cursor
+	ErrInvalidOptions = errors.New(ErrInvalidListOptions)
+
+	// ErrInterrupted matches errors caused by a canceled context or a canceled statement
+	ErrInterrupted = errors.New(ErrCanceled)
+)
+
 type Error struct {
-	Err error
-	Msg string
+	Err  error
+	Msg  string
+	Kind error
 }
 
 // NewError creates an Error that hides database error details behind Unwrap
@@ -11,17 +47,30 @@ func NewError(err error) error {
 		return nil
 	}
 
-	if includes(storeMessages, err.Error()) {
+	if errors.Is(err, ErrNotFound) || isClassified(err) {
 		return err
 	}
 
-	msg := dbMessages[err.Error()]
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return newValidationError(validationErrs)
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Err: err, Msg: ErrCanceled, Kind: ErrInterrupted}
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr) {
+		return &Error{Err: err, Msg: ErrConnectionFailed, Kind: ErrConnection}
+	}
 
-	if msg == "" {
-		msg = ErrUnknown
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return classify(pqErr)
 	}
 
-	return &Error{err, msg}
+	return &Error{Err: err, Msg: ErrUnknown}
 }
 
 func (e Error) Error() string {
@@ -32,21 +81,255 @@ func (e Error) Unwrap() error {
 	return e.Err
 }
 
+// Is reports whether the error is of the kind target, such as ErrConflict
+func (e Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// isClassified reports whether err was already created by the store
+func isClassified(err error) bool {
+	var storeErr *Error
+	var membershipsErr *MembershipsExistError
+	var versionErr *VersionConflictError
+	var validationErr *ValidationError
+	var existsErr *AlreadyExistsError
+	var missingErr *MissingIdsError
+
+	return errors.As(err, &storeErr) ||
+		errors.As(err, &membershipsErr) ||
+		errors.As(err, &versionErr) ||
+		errors.As(err, &validationErr) ||
+		errors.As(err, &existsErr) ||
+		errors.As(err, &missingErr)
+}
+
+// classify creates an Error from a database error by its SQLSTATE code and the constraint it violates
+func classify(err *pq.Error) error {
+	switch err.Code.Name() {
+	case "unique_violation":
+		if field, ok := uniqueFields[err.Constraint]; ok {
+			return &AlreadyExistsError{Field: field, Err: err}
+		}
+
+		return &Error{Err: err, Msg: constraintMessage(err, ErrResourceExists), Kind: ErrConflict}
+	case "foreign_key_violation":
+		// the referenced side of a key reports "update or delete on table", the referencing side "insert or update"
+		if msg, ok := referencedMessages[err.Constraint]; ok && strings.HasPrefix(err.Message, "update or delete") {
+			return &Error{Err: err, Msg: msg, Kind: ErrConflict}
+		}
+
+		return &Error{Err: err, Msg: constraintMessage(err, ErrReferenceDNE), Kind: ErrForeignKey}
+	case "query_canceled":
+		return &Error{Err: err, Msg: ErrCanceled, Kind: ErrInterrupted}
+	case "cannot_connect_now", "too_many_connections":
+		return &Error{Err: err, Msg: ErrConnectionFailed, Kind: ErrConnection}
+	}
+
+	if err.Code.Class() == "08" {
+		return &Error{Err: err, Msg: ErrConnectionFailed, Kind: ErrConnection}
+	}
+
+	return &Error{Err: err, Msg: ErrUnknown}
+}
+
+// constraintMessage returns the message of the constraint an error violates, or msg for other constraints
+func constraintMessage(err *pq.Error, msg string) string {
+	if m, ok := constraintMessages[err.Constraint]; ok {
+		return m
+	}
+
+	return msg
+}
+
+// errors of memberships that are detected without hitting a constraint
+var errGroupOrUserDNE = &Error{Msg: ErrGroupOrUserDNE, Kind: ErrForeignKey}
+var errGroupUserAlreadyLinked = &Error{Msg: ErrGroupUserAlreadyLinked, Kind: ErrConflict}
+
+// errors of list options
+var errInvalidCursor = &Error{Msg: ErrInvalidCursor, Kind: ErrInvalidOptions}
+var errInvalidField = &Error{Msg: ErrInvalidField, Kind: ErrInvalidOptions}
+var errInvalidFilter = &Error{Msg: ErrInvalidFilter, Kind: ErrInvalidOptions}
+
+// errors of group moves that are detected before updating the group
+var errParentGroupDNE = &Error{Msg: ErrParentGroupDNE, Kind: ErrForeignKey}
+var errGroupCycle = &Error{Msg: ErrGroupCycle, Kind: ErrConflict}
+
+// VersionConflictError is returned when a versioned update finds the resource at another version than expected
+type VersionConflictError struct {
+	Expected int64
+	Actual   int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: expected version %d, found version %d", ErrVersionConflict, e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrConflict
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// AlreadyExistsError is returned when a resource has the same value as another resource in a field that must be unique.
+// The field is named as in the JSON representation of the resource
+type AlreadyExistsError struct {
+	Field string
+	Err   error
+}
+
+func (e *AlreadyExistsError) Error() string {
+	return fmt.Sprintf("%s: %s is taken", ErrResourceExists, e.Field)
+}
+
+func (e *AlreadyExistsError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrConflict
+func (e *AlreadyExistsError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// MissingIdsError is returned by the lookups by IDs of a Strict store when some of the resources do not exist
+type MissingIdsError struct {
+	Ids []int64
+}
+
+func (e *MissingIdsError) Error() string {
+	ids := make([]string, len(e.Ids))
+
+	for i, id := range e.Ids {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrResourceDNE, strings.Join(ids, ", "))
+}
+
+// Is reports whether target is ErrNotFound
+func (e *MissingIdsError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ValidationError is returned when a resource fails validation, listing every violation
+type ValidationError struct {
+	Violations []Violation `json:"violations"`
+}
+
+// Violation is a validation rule that a field of a resource does not satisfy.
+// Fields are named as in the JSON representation of the resource
+type Violation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// requiredError returns a ValidationError for a missing field that is only required by some operations
+func requiredError(field string) *ValidationError {
+	return &ValidationError{Violations: []Violation{
+		{Field: field, Rule: "required", Message: fmt.Sprintf("%s is required", field)},
+	}}
+}
+
+func newValidationError(errs validator.ValidationErrors) *ValidationError {
+	violations := make([]Violation, len(errs))
+
+	for i, fe := range errs {
+		rule := fe.Tag()
+
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+
+		violations[i] = Violation{Field: fe.Field(), Rule: rule, Message: violationMessage(fe)}
+	}
+
+	return &ValidationError{Violations: violations}
+}
+
+// violationMessage describes a failed validation rule in plain words
+func violationMessage(fe validator.FieldError) string {
+	unit := ""
+
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "lte", "max":
+		return fmt.Sprintf("%s must be at most %s%s", fe.Field(), fe.Param(), unit)
+	case "gte", "min":
+		return fmt.Sprintf("%s must be at least %s%s", fe.Field(), fe.Param(), unit)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s%s", fe.Field(), fe.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("%s must be more than %s%s", fe.Field(), fe.Param(), unit)
+	case "len":
+		return fmt.Sprintf("%s must be exactly %s%s", fe.Field(), fe.Param(), unit)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", fe.Field(), strings.Join(strings.Fields(fe.Param()), ", "))
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", fe.Field())
+	case "username":
+		return fmt.Sprintf("%s may only contain letters, digits, '.', '_' and '-'", fe.Field())
+	}
+
+	return fmt.Sprintf("%s does not satisfy %s", fe.Field(), fe.Tag())
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidResource, strings.Join(messages, ", "))
+}
+
 // error messages that originate from the data store layer that do not contain sensitive database implementation details
 const ErrResourceDNE = "resource does not exist"
-var storeMessages = []string{
-	ErrResourceDNE,
-}
+const ErrInvalidCursor = "invalid cursor"
+const ErrInvalidField = "invalid field"
+const ErrInvalidFilter = "invalid filter"
+const ErrInvalidListOptions = "invalid list options"
+const ErrInvalidResource = "invalid resource"
+const ErrMembershipsExist = "resource has group memberships"
+const ErrVersionConflict = "resource version has changed"
+const ErrResourceConflict = "resource conflicts with its current state"
+const ErrResourceExists = "resource already exists"
+const ErrReferenceDNE = "referenced resource does not exist"
+const ErrConnectionFailed = "unable to connect to the database"
 
-// error messages that originate from the database and contain potentially sensitive database implementation details
+// error messages of database errors, whose details are hidden behind Unwrap
 const DbErrGroupUserAlreadyLinked = "pq: duplicate key value violates unique constraint \"group_user_pkey\""
 const ErrGroupUserAlreadyLinked = "group already linked to user"
 const DbErrGroupOrUserDNE = "pq: insert or update on table \"group_user\" violates foreign key constraint \"group_user_group_id_fkey\""
 const ErrGroupOrUserDNE = "group or user does not exist"
-var dbMessages = map[string]string{
-	ErrResourceDNE: ErrResourceDNE,
-	DbErrGroupOrUserDNE: ErrGroupOrUserDNE,
-	DbErrGroupUserAlreadyLinked: ErrGroupUserAlreadyLinked,
+const DbErrQueryCanceled = "pq: canceling statement due to user request"
+const ErrCanceled = "operation canceled"
+const ErrParentGroupDNE = "parent group does not exist"
+const ErrGroupCycle = "group cannot be moved under itself or its descendants"
+const ErrGroupHasChildren = "group has child groups"
+
+// the messages of errors violating known constraints
+var constraintMessages = map[string]string{
+	"group_user_pkey":          ErrGroupUserAlreadyLinked,
+	"group_user_group_id_fkey": ErrGroupOrUserDNE,
+	"group_user_user_id_fkey":  ErrGroupOrUserDNE,
+	"group_parent_id_fkey":     ErrParentGroupDNE,
+}
+
+// the messages of errors deleting a row that rows of a known foreign key still reference
+var referencedMessages = map[string]string{
+	"group_parent_id_fkey": ErrGroupHasChildren,
+}
+
+// the fields of unique indexes, named as in the JSON representation of their resource
+var uniqueFields = map[string]string{
+	"user_email_key":    "email",
+	"user_username_key": "username",
+	"group_name_key":    "name",
 }
 
 // fallthrough error message
@@ -0,0 +1,316 @@
+package data
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/gocraft/dbr/v2"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultPageSize is the number of records in a page when ListOptions.Limit is not set
+const DefaultPageSize = 50
+
+// MaxPageSize is the largest number of records a single page can hold
+const MaxPageSize = 1000
+
+// ListOptions controls which page of records a list query returns
+type ListOptions struct {
+	// Limit is the maximum number of records in the page,
+	// defaulting to DefaultPageSize and capped at MaxPageSize
+	Limit int
+
+	// Cursor is the NextCursor of the previous page, or empty for the first page.
+	// A cursor is only valid for the Sort it was created with
+	Cursor string
+
+	// Filters restrict the records to those matching every filter
+	Filters []Filter
+
+	// Sort orders the records, by primary key if empty
+	Sort []Sort
+
+	// Count requests the total number of records matching the filters across all pages
+	Count bool
+
+	// Since restricts the records to those created or updated at or after the time, if it is set.
+	// Lookups through memberships restrict them to those linked at or after it
+	Since time.Time
+}
+
+// UserList is a page of users
+type UserList struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"nextCursor"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// GroupList is a page of groups
+type GroupList struct {
+	Groups     []Group `json:"groups"`
+	NextCursor string  `json:"nextCursor"`
+	Total      *int64  `json:"total,omitempty"`
+}
+
+// MembershipList is a page of memberships
+type MembershipList struct {
+	Memberships []Membership `json:"memberships"`
+	NextCursor  string       `json:"nextCursor"`
+	Total       *int64       `json:"total,omitempty"`
+}
+
+// cursor holds the sort values of the last row of a page.
+// It is handed to callers as an opaque token
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// sortKey identifies a sort order, so that a cursor is not applied to a query sorted differently
+func sortKey(fields []reflect.StructField, desc []bool) string {
+	keys := make([]string, len(fields))
+
+	for i, f := range fields {
+		keys[i] = f.Tag.Get("db")
+
+		if desc[i] {
+			keys[i] = "-" + keys[i]
+		}
+	}
+
+	return strings.Join(keys, ",")
+}
+
+func encodeCursor(key string, fields []reflect.StructField, row reflect.Value) (string, error) {
+	c := cursor{Sort: key}
+
+	for _, f := range fields {
+		v, err := json.Marshal(row.FieldByIndex(f.Index).Interface())
+
+		if err != nil {
+			return "", err
+		}
+
+		c.Values = append(c.Values, v)
+	}
+
+	j, err := json.Marshal(c)
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(j), nil
+}
+
+func decodeCursor(s string, key string, fields []reflect.StructField) ([]interface{}, error) {
+	var c cursor
+
+	j, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	if err := json.Unmarshal(j, &c); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	if c.Sort != key || len(c.Values) != len(fields) {
+		return nil, errInvalidCursor
+	}
+
+	values := make([]interface{}, len(fields))
+
+	for i, f := range fields {
+		v := reflect.New(f.Type)
+
+		if err := json.Unmarshal(c.Values[i], v.Interface()); err != nil {
+			return nil, errInvalidCursor
+		}
+
+		values[i] = v.Elem().Interface()
+	}
+
+	return values, nil
+}
+
+func pageSize(limit int) int {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+
+	return limit
+}
+
+// list loads a page of rows from a table into dest, which must be a pointer to a slice of the schema's model.
+// It returns the cursor of the next page, or an empty string if there is none,
+// and the total number of matching rows if the options request it
+func (s *Store) list(ctx context.Context, sc *schema, opts ListOptions, dest interface{}) (string, *int64, error) {
+	stmt := s.db.
+		Select(quotes(sc.table) + ".*").
+		From(quotes(sc.table))
+
+	if _, ok := sc.fields["deleted_at"]; ok {
+		stmt.Where(quotes(sc.table) + ".deleted_at is null")
+	}
+
+	applySince(stmt, quotes(sc.table)+".updated_at", opts)
+
+	return s.loadPage(ctx, stmt, sc, opts, dest)
+}
+
+// applySince restricts a statement to rows whose time column is at or after the options' Since, if it is set
+func applySince(stmt *dbr.SelectStmt, column string, opts ListOptions) {
+	if !opts.Since.IsZero() {
+		stmt.Where(column+" >= ?", sqlValue(opts.Since))
+	}
+}
+
+// loadPage applies a ListOptions to a statement selecting rows of the schema's table and loads the page into dest.
+// One row more than the page size is selected, so that the presence of a next page can be detected
+func (s *Store) loadPage(ctx context.Context, stmt *dbr.SelectStmt, sc *schema, opts ListOptions, dest interface{}) (string, *int64, error) {
+	if err := sc.applyFilters(stmt, opts.Filters); err != nil {
+		return "", nil, err
+	}
+
+	var total *int64
+
+	// the count is taken before the statement is narrowed down to a single page
+	if opts.Count {
+		total = new(int64)
+
+		err := s.db.
+			Select("count(*)").
+			From(stmt.As("filtered")).
+			LoadOneContext(ctx, total)
+
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	fields, desc, err := sc.applySort(stmt, opts.Sort)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := sortKey(fields, desc)
+
+	if opts.Cursor != "" {
+		values, err := decodeCursor(opts.Cursor, key, fields)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		stmt.Where(sc.after(fields, desc, values))
+	}
+
+	limit := pageSize(opts.Limit)
+	stmt.Limit(uint64(limit + 1))
+
+	if _, err := stmt.LoadContext(ctx, dest); err != nil {
+		return "", nil, err
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+
+	if rows.Len() <= limit {
+		return "", total, nil
+	}
+
+	rows.Set(rows.Slice(0, limit))
+	next, err := encodeCursor(key, fields, rows.Index(limit-1))
+
+	return next, total, err
+}
+
+// loadPages is like loadPage, but loads a page for each of the ids looked up by a statement of selectJunctionAny,
+// whose rows are ranked within their lookup id so that the pages are loaded in one query.
+// The rows of dest embed the model first and carry the lookup id in a LookupId field.
+// It returns the cursors of the ids that have a next page. Totals are not counted
+func (s *Store) loadPages(ctx context.Context, stmt *dbr.SelectStmt, sc *schema, opts ListOptions, dest interface{}) (map[int64]string, error) {
+	if err := sc.applyFilters(stmt, opts.Filters); err != nil {
+		return nil, err
+	}
+
+	fields, desc, err := sc.order(opts.Sort)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := sortKey(fields, desc)
+
+	if opts.Cursor != "" {
+		values, err := decodeCursor(opts.Cursor, key, fields)
+
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.Where(sc.after(fields, desc, values))
+	}
+
+	// the ranking query selects from the statement, so its columns are no longer qualified by the table
+	order := make([]string, len(fields))
+
+	for i, f := range fields {
+		order[i] = quotes(f.Tag.Get("db"))
+
+		if desc[i] {
+			order[i] += " desc"
+		}
+	}
+
+	ranked := s.db.
+		Select("*", fmt.Sprintf("row_number() over (partition by lookup_id order by %s) as lookup_rank", strings.Join(order, ", "))).
+		From(stmt.As("page"))
+
+	limit := pageSize(opts.Limit)
+
+	_, err = s.db.
+		Select("*").
+		From(ranked.As("ranked")).
+		Where("lookup_rank <= ?", limit+1).
+		OrderBy("lookup_id").
+		OrderBy("lookup_rank").
+		LoadContext(ctx, dest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	page := reflect.MakeSlice(rows.Type(), 0, rows.Len())
+	counts := map[int64]int{}
+	next := map[int64]string{}
+
+	// the row after the last row of a page only shows that there is a next page
+	for i := 0; i < rows.Len(); i++ {
+		id := rows.Index(i).FieldByName("LookupId").Int()
+		counts[id]++
+
+		if counts[id] <= limit {
+			page = reflect.Append(page, rows.Index(i))
+			continue
+		}
+
+		if next[id], err = encodeCursor(key, fields, rows.Index(i-1).Field(0)); err != nil {
+			return nil, err
+		}
+	}
+
+	rows.Set(page)
+
+	return next, nil
+}
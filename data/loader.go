@@ -0,0 +1,191 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// how long a Loader waits for further lookups before running a batch
+const loaderWait = 2 * time.Millisecond
+
+// how long a batch may take, since it does not end with the lookups that wait for it
+const loaderTimeout = 30 * time.Second
+
+// Loader batches lookups of the users of groups and of the groups of users, such as those made while resolving
+// the fields of a GraphQL query, into one query per batch instead of one per id. Lookups made within a short wait
+// of each other, or up to bulkBatchSize of them, share a batch.
+// A Loader caches what it loads, other than failed lookups, so it is meant to serve a single request.
+// The lookups of ids that do not exist fail with ErrNotFound if the store is Strict, without failing the rest of their batch
+type Loader struct {
+	s      *Store
+	users  *batcher[[]User]
+	groups *batcher[[]Group]
+
+	// the batchers of paged lookups, by their options
+	mu         sync.Mutex
+	userPages  map[string]*batcher[*UserList]
+	groupPages map[string]*batcher[*GroupList]
+}
+
+// NewLoader creates a Loader that reads through s
+func (s *Store) NewLoader() *Loader {
+	return &Loader{
+		s:          s,
+		users:      newBatcher(s.GetUsersByGroupIdsContext),
+		groups:     newBatcher(s.GetGroupsByUserIdsContext),
+		userPages:  map[string]*batcher[*UserList]{},
+		groupPages: map[string]*batcher[*GroupList]{},
+	}
+}
+
+// UsersByGroupId returns the users that belong to a group
+func (l *Loader) UsersByGroupId(ctx context.Context, groupId int64) ([]User, error) {
+	return l.users.load(ctx, groupId)
+}
+
+// GroupsByUserId returns the groups that contain a user
+func (l *Loader) GroupsByUserId(ctx context.Context, userId int64) ([]Group, error) {
+	return l.groups.load(ctx, userId)
+}
+
+// ListUsersByGroupId returns a page of the users that belong to a group.
+// Lookups with the same options share batches, and Count is ignored
+func (l *Loader) ListUsersByGroupId(ctx context.Context, groupId int64, opts ListOptions) (*UserList, error) {
+	return pageBatcher(&l.mu, l.userPages, opts, l.s.ListUsersByGroupIdsContext).load(ctx, groupId)
+}
+
+// ListGroupsByUserId returns a page of the groups that contain a user.
+// Lookups with the same options share batches, and Count is ignored
+func (l *Loader) ListGroupsByUserId(ctx context.Context, userId int64, opts ListOptions) (*GroupList, error) {
+	return pageBatcher(&l.mu, l.groupPages, opts, l.s.ListGroupsByUserIdsContext).load(ctx, userId)
+}
+
+// pageBatcher returns the batcher of the paged lookups with the given options, creating it on first use
+func pageBatcher[L any](mu *sync.Mutex, batchers map[string]*batcher[L], opts ListOptions, fetch func(ctx context.Context, ids []int64, opts ListOptions) (map[int64]L, []int64, error)) *batcher[L] {
+	key := fmt.Sprintf("%v", opts)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	b, ok := batchers[key]
+
+	if !ok {
+		b = newBatcher(func(ctx context.Context, ids []int64) (map[int64]L, []int64, error) {
+			return fetch(ctx, ids, opts)
+		})
+		batchers[key] = b
+	}
+
+	return b
+}
+
+// batcher collects the ids looked up within a wait of each other and fetches them together
+type batcher[V any] struct {
+	fetch func(ctx context.Context, ids []int64) (map[int64]V, []int64, error)
+
+	mu      sync.Mutex
+	results map[int64]*batchResult[V]
+	pending []int64
+}
+
+// batchResult is the outcome of looking up an id, which is ready once done is closed
+type batchResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func newBatcher[V any](fetch func(ctx context.Context, ids []int64) (map[int64]V, []int64, error)) *batcher[V] {
+	return &batcher[V]{fetch: fetch, results: map[int64]*batchResult[V]{}}
+}
+
+// load looks up an id in the next batch, unless it has been looked up already.
+// The batch keeps the values of the context of the lookup that started it, but not its cancellation,
+// so that a lookup that gives up does not fail the others of its batch
+func (b *batcher[V]) load(ctx context.Context, id int64) (V, error) {
+	b.mu.Lock()
+	r, ok := b.results[id]
+
+	if !ok {
+		r = &batchResult[V]{done: make(chan struct{})}
+		b.results[id] = r
+		b.pending = append(b.pending, id)
+
+		batchCtx := context.WithoutCancel(ctx)
+
+		switch len(b.pending) {
+		case 1:
+			time.AfterFunc(loaderWait, func() { b.dispatch(batchCtx) })
+		case bulkBatchSize:
+			go b.dispatch(batchCtx)
+		}
+	}
+
+	b.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch fetches the pending ids and hands each lookup its value, or the error of the fetch.
+// Failed ids are not cached, so that later lookups of them fetch them again.
+// A *MissingIdsError only fails the lookups of the missing ids
+func (b *batcher[V]) dispatch(ctx context.Context) {
+	b.mu.Lock()
+	ids := b.pending
+	b.pending = nil
+	results := make([]*batchResult[V], len(ids))
+
+	for i, id := range ids {
+		results[i] = b.results[id]
+	}
+
+	b.mu.Unlock()
+
+	if len(ids) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, loaderTimeout)
+	defer cancel()
+
+	values, missing, err := b.fetch(ctx, ids)
+	var missingErr *MissingIdsError
+	notFound := errors.As(err, &missingErr)
+
+	if notFound {
+		err = nil
+	}
+
+	if err != nil {
+		b.mu.Lock()
+
+		for i, id := range ids {
+			if b.results[id] == results[i] {
+				delete(b.results, id)
+			}
+		}
+
+		b.mu.Unlock()
+	}
+
+	for i, id := range ids {
+		results[i].value = values[id]
+		results[i].err = err
+
+		if notFound && slices.Contains(missing, id) {
+			results[i].err = ErrNotFound
+		}
+
+		close(results[i].done)
+	}
+}
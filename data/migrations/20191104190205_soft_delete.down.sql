@@ -0,0 +1,2 @@
+alter table "user" drop column if exists deleted_at;
+alter table "group" drop column if exists deleted_at;
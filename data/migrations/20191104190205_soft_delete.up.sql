@@ -0,0 +1,2 @@
+alter table "user" add column deleted_at timestamptz;
+alter table "group" add column deleted_at timestamptz;
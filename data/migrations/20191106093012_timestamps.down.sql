@@ -0,0 +1,5 @@
+alter table "user" drop column if exists created_at;
+alter table "user" drop column if exists updated_at;
+alter table "group" drop column if exists created_at;
+alter table "group" drop column if exists updated_at;
+alter table group_user drop column if exists linked_at;
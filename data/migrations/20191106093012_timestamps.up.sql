@@ -0,0 +1,5 @@
+alter table "user" add column created_at timestamptz not null default now();
+alter table "user" add column updated_at timestamptz not null default now();
+alter table "group" add column created_at timestamptz not null default now();
+alter table "group" add column updated_at timestamptz not null default now();
+alter table group_user add column linked_at timestamptz not null default now();
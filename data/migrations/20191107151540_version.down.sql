@@ -0,0 +1,2 @@
+alter table "user" drop column if exists version;
+alter table "group" drop column if exists version;
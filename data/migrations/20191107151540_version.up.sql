@@ -0,0 +1,2 @@
+alter table "user" add column version int not null default 1;
+alter table "group" add column version int not null default 1;
@@ -0,0 +1,3 @@
+alter table group_user drop column if exists attributes;
+alter table group_user drop constraint if exists group_user_role_check;
+alter table group_user drop column if exists role;
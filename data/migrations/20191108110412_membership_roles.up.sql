@@ -0,0 +1,3 @@
+alter table group_user add column role varchar(20) not null default 'member';
+alter table group_user add constraint group_user_role_check check (role in ('owner', 'admin', 'member'));
+alter table group_user add column attributes jsonb;
@@ -0,0 +1,2 @@
+drop index if exists group_parent_id_idx;
+alter table "group" drop column if exists parent_id;
@@ -0,0 +1,2 @@
+alter table "group" add column parent_id int references "group"(id);
+create index group_parent_id_idx on "group" (parent_id);
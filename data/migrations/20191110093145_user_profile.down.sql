@@ -0,0 +1,4 @@
+drop index if exists user_username_key;
+drop index if exists user_email_key;
+alter table "user" drop column if exists username;
+alter table "user" drop column if exists email;
@@ -0,0 +1,4 @@
+alter table "user" add column email varchar(254);
+alter table "user" add column username varchar(50);
+create unique index user_email_key on "user" (lower(email));
+create unique index user_username_key on "user" (lower(username));
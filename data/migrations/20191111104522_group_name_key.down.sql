@@ -0,0 +1,2 @@
+-- the up migration does not change any groups, so dropping the key is all there is to undo
+drop index if exists group_name_key;
@@ -0,0 +1,23 @@
+-- the key cannot be created while live groups share a name under the same parent, ignoring case.
+-- Those groups are listed rather than merged or renamed, since only their owners can tell which should change
+do $$
+declare
+    duplicates text;
+begin
+    select string_agg(d.groups, '; ') into duplicates
+    from (
+        select format('ids %s named %L under parent %s', string_agg(id::text, ', ' order by id), min(name), coalesce(min(parent_id)::text, 'none')) as groups
+        from "group"
+        where deleted_at is null
+        group by coalesce(parent_id, 0), lower(name)
+        having count(*) > 1
+    ) d;
+
+    if duplicates is not null then
+        raise exception 'cannot create group_name_key, rename or delete the groups with duplicate names first: %', duplicates;
+    end if;
+end
+$$;
+
+-- soft-deleted groups do not hold on to their names
+create unique index group_name_key on "group" (coalesce(parent_id, 0), lower(name)) where deleted_at is null;
@@ -1,12 +1,86 @@
 package data
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/go-playground/validator.v9"
+	"regexp"
+	"time"
+)
+
 type User struct {
-	Id        int64  `db:"id" json:"id"`
-	FirstName string `db:"first_name" json:"firstName" validate:"required,lte=100"`
-	LastName  string `db:"last_name" json:"lastName" validate:"required,lte=100"`
+	Id        int64      `db:"id" json:"id"`
+	FirstName string     `db:"first_name" json:"firstName" validate:"required,lte=100"`
+	LastName  string     `db:"last_name" json:"lastName" validate:"required,lte=100"`
+	Email     *string    `db:"email" json:"email,omitempty" validate:"omitempty,lte=254,email"`
+	Username  *string    `db:"username" json:"username,omitempty" validate:"omitempty,gte=3,lte=50,username"`
+	Version   int64      `db:"version" json:"version"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+}
+
+// UsernamePattern matches the characters that usernames may contain
+var UsernamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// isUsername validates that a username only contains letters, digits, dots, underscores and hyphens
+func isUsername(fl validator.FieldLevel) bool {
+	return UsernamePattern.MatchString(fl.Field().String())
 }
 
 type Group struct {
-	Id   int64  `db:"id" json:"id"`
-	Name string `db:"name" json:"name" validate:"required,lte=100"`
+	Id        int64      `db:"id" json:"id"`
+	Name      string     `db:"name" json:"name" validate:"required,lte=100"`
+	ParentId  *int64     `db:"parent_id" json:"parentId,omitempty"`
+	Version   int64      `db:"version" json:"version"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+}
+
+type Membership struct {
+	GroupId    int64      `db:"group_id" json:"groupId"`
+	UserId     int64      `db:"user_id" json:"userId"`
+	Role       Role       `db:"role" json:"role" validate:"oneof=owner admin member"`
+	Attributes Attributes `db:"attributes" json:"attributes,omitempty"`
+	LinkedAt   time.Time  `db:"linked_at" json:"linkedAt"`
+}
+
+// Role is the role of a user in a group
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+)
+
+// Attributes are free-form properties of a membership, stored as a JSON object
+type Attributes map[string]interface{}
+
+// Value implements driver.Valuer, storing nil attributes as null
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(a)
+
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (a *Attributes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+
+	return fmt.Errorf("cannot scan %T into Attributes", src)
 }
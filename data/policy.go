@@ -0,0 +1,112 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DeletePolicy decides what happens to the group memberships of a user or group being deleted
+type DeletePolicy int
+
+const (
+	// DeleteKeep leaves the memberships in place. They are hidden while the user or group is soft-deleted
+	// and return when it is restored. Since memberships cannot outlive a purged user or group,
+	// purges treat DeleteKeep like DeleteRestrict
+	DeleteKeep DeletePolicy = iota
+
+	// DeleteRestrict refuses to delete a user or group that has memberships
+	DeleteRestrict
+
+	// DeleteCascade removes the memberships in the same transaction as the user or group
+	DeleteCascade
+)
+
+// MembershipsExistError is returned when DeleteRestrict prevents a deletion
+type MembershipsExistError struct {
+	Memberships []Membership
+}
+
+func (e *MembershipsExistError) Error() string {
+	links := make([]string, len(e.Memberships))
+
+	for i, m := range e.Memberships {
+		links[i] = fmt.Sprintf("group %d/user %d", m.GroupId, m.UserId)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrMembershipsExist, strings.Join(links, ", "))
+}
+
+// Is reports whether target is ErrConflict
+func (e *MembershipsExistError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func deletePolicy(policy []DeletePolicy) DeletePolicy {
+	if len(policy) == 0 {
+		return DeleteKeep
+	}
+
+	return policy[0]
+}
+
+// deleteWithPolicy applies a delete policy to the memberships referencing a row of table through the group_user column fk,
+// then runs del in the same transaction
+func (s *Store) deleteWithPolicy(ctx context.Context, table string, fk string, id int64, policy DeletePolicy, purge bool, del func(tx *Store) error) error {
+	if policy == DeleteKeep && purge {
+		policy = DeleteRestrict
+	}
+
+	if policy == DeleteKeep {
+		return del(s)
+	}
+
+	return s.WithTx(ctx, func(tx *Store) error {
+		switch policy {
+		case DeleteRestrict:
+			// locking the row blocks memberships from being added until the delete commits,
+			// since linking takes a key share lock on it through the foreign key
+			var locked []int64
+
+			_, err := tx.db.
+				Select("id").
+				From(quotes(table)).
+				Where("id = ?", id).
+				Suffix("for update").
+				LoadContext(ctx, &locked)
+
+			if err != nil {
+				return err
+			}
+
+			var memberships []Membership
+
+			_, err = tx.db.
+				Select("*").
+				From("group_user").
+				Where(fk+" = ?", id).
+				OrderAsc("group_id").
+				OrderAsc("user_id").
+				LoadContext(ctx, &memberships)
+
+			if err != nil {
+				return err
+			}
+
+			if len(memberships) > 0 {
+				return &MembershipsExistError{Memberships: memberships}
+			}
+		case DeleteCascade:
+			_, err := tx.db.
+				DeleteFrom("group_user").
+				Where(fk+" = ?", id).
+				ExecContext(ctx)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return del(tx)
+	})
+}
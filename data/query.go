@@ -0,0 +1,333 @@
+package data
+
+import (
+	"fmt"
+	"github.com/gocraft/dbr/v2"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Op is the comparison operator of a Filter
+type Op string
+
+const (
+	OpEq       Op = "="
+	OpNe       Op = "!="
+	OpLt       Op = "<"
+	OpLte      Op = "<="
+	OpGt       Op = ">"
+	OpGte      Op = ">="
+	OpPrefix   Op = "^=" // case-insensitive prefix match
+	OpContains Op = "~=" // case-insensitive substring match
+)
+
+// two-character operators come first so that "<=" is not parsed as "<"
+var ops = []Op{OpNe, OpLte, OpGte, OpPrefix, OpContains, OpEq, OpLt, OpGt}
+
+// Filter restricts a list query to records whose field compares to Value.
+// Field is either the json or the db name of a struct field
+type Filter struct {
+	Field string
+	Op    Op
+	Value interface{}
+}
+
+// Sort orders a list query by a field.
+// Field is either the json or the db name of a struct field
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseFilter parses an expression such as "lastName~=pee" into a Filter
+func ParseFilter(expr string) (Filter, error) {
+	for i := 1; i < len(expr); i++ {
+		for _, op := range ops {
+			if strings.HasPrefix(expr[i:], string(op)) {
+				return Filter{
+					Field: expr[:i],
+					Op:    op,
+					Value: expr[i+len(op):],
+				}, nil
+			}
+		}
+	}
+
+	return Filter{}, fmt.Errorf("failed to parse filter %q", expr)
+}
+
+// ParseSort parses an expression such as "-firstName" into a Sort.
+// A leading "-" sorts in descending order, a leading "+" or none in ascending order
+func ParseSort(expr string) Sort {
+	if strings.HasPrefix(expr, "-") {
+		return Sort{Field: expr[1:], Desc: true}
+	}
+
+	return Sort{Field: strings.TrimPrefix(expr, "+")}
+}
+
+// schema describes the filterable and sortable columns of a table,
+// as derived from the db struct tags of its model
+type schema struct {
+	table  string
+	key    []string
+	fields map[string]reflect.StructField
+}
+
+var userSchema = userResource.schema
+var groupSchema = groupResource.schema
+var membershipSchema = newSchema("group_user", Membership{}, "group_id", "user_id")
+
+// newSchema creates the schema of a table from its model and the columns of its primary key
+func newSchema(table string, model interface{}, key ...string) *schema {
+	sc := &schema{
+		table:  table,
+		key:    key,
+		fields: map[string]reflect.StructField{},
+	}
+
+	t := reflect.TypeOf(model)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		col := f.Tag.Get("db")
+
+		if col == "" || col == "-" {
+			continue
+		}
+
+		sc.fields[col] = f
+
+		if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			sc.fields[name] = f
+		}
+	}
+
+	return sc
+}
+
+func (sc *schema) field(name string) (reflect.StructField, error) {
+	f, ok := sc.fields[name]
+
+	if !ok {
+		return f, errInvalidField
+	}
+
+	return f, nil
+}
+
+// column returns the table-qualified column of a field, unquoted for use in dbr conditions
+func (sc *schema) column(f reflect.StructField) string {
+	return sc.table + "." + f.Tag.Get("db")
+}
+
+// quotedColumn returns the table-qualified column of a field, quoted for use in raw sql
+func (sc *schema) quotedColumn(f reflect.StructField) string {
+	return quotes(sc.table) + "." + quotes(f.Tag.Get("db"))
+}
+
+// order resolves the sort fields of a query, appending the primary key as a tie-breaker
+// so that every row has a distinct position for cursors to refer to
+func (sc *schema) order(sorts []Sort) ([]reflect.StructField, []bool, error) {
+	var fields []reflect.StructField
+	var desc []bool
+	sorted := map[string]bool{}
+
+	for _, sort := range sorts {
+		f, err := sc.field(sort.Field)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// null values have no position in a keyset, so nullable fields are not sortable
+		if k := f.Type.Kind(); k == reflect.Ptr || k == reflect.Map {
+			return nil, nil, errInvalidField
+		}
+
+		fields = append(fields, f)
+		desc = append(desc, sort.Desc)
+		sorted[f.Tag.Get("db")] = true
+	}
+
+	for _, col := range sc.key {
+		if !sorted[col] {
+			f, _ := sc.field(col)
+			fields = append(fields, f)
+			desc = append(desc, false)
+		}
+	}
+
+	return fields, desc, nil
+}
+
+// where converts a Filter into a condition on the schema's table
+func (sc *schema) where(filter Filter) (dbr.Builder, error) {
+	f, err := sc.field(filter.Field)
+
+	if err != nil {
+		return nil, err
+	}
+
+	col := sc.column(f)
+
+	if filter.Op == OpPrefix || filter.Op == OpContains {
+		s, ok := filter.Value.(string)
+
+		if !ok || indirect(f.Type).Kind() != reflect.String {
+			return nil, errInvalidFilter
+		}
+
+		pattern := escapeLike(s) + "%"
+
+		if filter.Op == OpContains {
+			pattern = "%" + pattern
+		}
+
+		return dbr.Expr(sc.quotedColumn(f)+" ILIKE ?", pattern), nil
+	}
+
+	value, err := convertValue(f.Type, filter.Value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch filter.Op {
+	case OpEq:
+		return dbr.Eq(col, value), nil
+	case OpNe:
+		return dbr.Neq(col, value), nil
+	case OpLt:
+		return dbr.Lt(col, value), nil
+	case OpLte:
+		return dbr.Lte(col, value), nil
+	case OpGt:
+		return dbr.Gt(col, value), nil
+	case OpGte:
+		return dbr.Gte(col, value), nil
+	}
+
+	return nil, errInvalidFilter
+}
+
+// applyFilters adds the filters of a ListOptions to a select statement
+func (sc *schema) applyFilters(stmt *dbr.SelectStmt, filters []Filter) error {
+	for _, filter := range filters {
+		cond, err := sc.where(filter)
+
+		if err != nil {
+			return err
+		}
+
+		stmt.Where(cond)
+	}
+
+	return nil
+}
+
+// applySort adds the sort order of a ListOptions to a select statement, and returns the resolved sort fields
+func (sc *schema) applySort(stmt *dbr.SelectStmt, sorts []Sort) ([]reflect.StructField, []bool, error) {
+	fields, desc, err := sc.order(sorts)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, f := range fields {
+		stmt.OrderDir(sc.quotedColumn(f), !desc[i])
+	}
+
+	return fields, desc, nil
+}
+
+// after returns a condition that matches the rows positioned after the given sort values
+func (sc *schema) after(fields []reflect.StructField, desc []bool, values []interface{}) dbr.Builder {
+	var or []dbr.Builder
+
+	for i := range fields {
+		var and []dbr.Builder
+
+		for j := 0; j < i; j++ {
+			and = append(and, dbr.Eq(sc.column(fields[j]), sqlValue(values[j])))
+		}
+
+		if desc[i] {
+			and = append(and, dbr.Lt(sc.column(fields[i]), sqlValue(values[i])))
+		} else {
+			and = append(and, dbr.Gt(sc.column(fields[i]), sqlValue(values[i])))
+		}
+
+		or = append(or, dbr.And(and...))
+	}
+
+	return dbr.Or(or...)
+}
+
+// convertValue converts a filter value, which is typically a string from user input,
+// into a value of the field's type
+func convertValue(t reflect.Type, v interface{}) (interface{}, error) {
+	s, ok := v.(string)
+
+	if !ok {
+		return sqlValue(v), nil
+	}
+
+	t = indirect(t)
+
+	if t == reflect.TypeOf(time.Time{}) {
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			return nil, errInvalidFilter
+		}
+
+		return s, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+
+		if err != nil {
+			return nil, errInvalidFilter
+		}
+
+		return i, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+
+		if err != nil {
+			return nil, errInvalidFilter
+		}
+
+		return b, nil
+	}
+
+	return nil, errInvalidFilter
+}
+
+// sqlValue formats times with their offset, since dbr would otherwise interpolate them without a time zone
+func sqlValue(v interface{}) interface{} {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	return v
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+
+	return t
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
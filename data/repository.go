@@ -0,0 +1,321 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Repository creates, reads, updates and deletes resources of type T, which is a struct
+// whose fields are mapped to the columns of a table by db tags.
+// The table must have the columns that the store manages for every resource: id, version, created_at, updated_at and deleted_at.
+// Every other column with a db tag is written on create, and the fields that also have a validate tag can be updated.
+// The table is named after T in snake case, unless T implements TableNamer
+type Repository[T any] struct {
+	s   *Store
+	res *resource
+}
+
+// TableNamer is implemented by resource types whose table is not named after the type
+type TableNamer interface {
+	TableName() string
+}
+
+// Page is a page of resources
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// NewRepository returns the repository of T on a store, which can be a transactional store passed by WithTx.
+// It panics if T is not a struct with the columns managed by the store
+func NewRepository[T any](s *Store) *Repository[T] {
+	return &Repository[T]{s: s, res: resourceOf(reflect.TypeOf((*T)(nil)).Elem())}
+}
+
+// UpdatableFields returns the names of the fields of T that updates can change, which are the fields that have validate tags.
+// It panics if T is not a struct with the columns managed by the store
+func UpdatableFields[T any]() []string {
+	res := resourceOf(reflect.TypeOf((*T)(nil)).Elem())
+	fields := make([]string, len(res.updatable))
+
+	for i, f := range res.updatable {
+		fields[i] = f.Name
+	}
+
+	return fields
+}
+
+// Create creates a new resource, setting its Id, version and timestamps
+func (r *Repository[T]) Create(record *T) (*T, error) {
+	return r.CreateContext(context.Background(), record)
+}
+
+// CreateContext creates a new resource, aborting if ctx is done
+func (r *Repository[T]) CreateContext(ctx context.Context, record *T) (*T, error) {
+	if err := r.s.validate.Struct(record); err != nil {
+		return nil, NewError(err)
+	}
+
+	if err := r.s.create(ctx, r.res.table, record, r.res.columns); err != nil {
+		return nil, NewError(err)
+	}
+
+	return record, nil
+}
+
+// Update updates an existing resource.
+// The variadic "fields" arg should contain the field names that should be updated
+func (r *Repository[T]) Update(id int64, record *T, fields ...string) error {
+	return r.UpdateContext(context.Background(), id, record, fields...)
+}
+
+// UpdateContext updates an existing resource, aborting if ctx is done
+func (r *Repository[T]) UpdateContext(ctx context.Context, id int64, record *T, fields ...string) error {
+	if err := r.s.validate.StructPartial(record, fields...); err != nil {
+		return NewError(err)
+	}
+
+	err := r.s.update(ctx, r.res.table, id, fields, r.res.sets(record)...)
+
+	return NewError(err)
+}
+
+// UpdateIfVersion updates an existing resource like Update, but only if the resource is still at the expected version.
+// If the resource has been changed since, a *VersionConflictError holding its current version is returned
+func (r *Repository[T]) UpdateIfVersion(id int64, version int64, record *T, fields ...string) error {
+	return r.UpdateIfVersionContext(context.Background(), id, version, record, fields...)
+}
+
+// UpdateIfVersionContext updates an existing resource if it is at the expected version, aborting if ctx is done
+func (r *Repository[T]) UpdateIfVersionContext(ctx context.Context, id int64, version int64, record *T, fields ...string) error {
+	if err := r.s.validate.StructPartial(record, fields...); err != nil {
+		return NewError(err)
+	}
+
+	err := r.s.updateVersion(ctx, r.res.table, id, &version, fields, r.res.sets(record)...)
+
+	return NewError(err)
+}
+
+// GetById gets a resource by ID. It returns a nil resource if there is none, unless the store is Strict
+func (r *Repository[T]) GetById(id int64) (*T, error) {
+	return r.GetByIdContext(context.Background(), id)
+}
+
+// GetByIdContext gets a resource by ID, aborting if ctx is done
+func (r *Repository[T]) GetByIdContext(ctx context.Context, id int64) (*T, error) {
+	record := new(T)
+	_, count, err := r.s.getById(ctx, r.res.table, id, record)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	if count == 0 {
+		return nil, r.s.notFound()
+	}
+
+	return record, nil
+}
+
+// GetByIds gets the resources with any of several IDs in one query. The resources are in the order of ids,
+// and the IDs without a resource are returned in that order too. A Strict store also returns a *MissingIdsError for them
+func (r *Repository[T]) GetByIds(ids []int64) ([]T, []int64, error) {
+	return r.GetByIdsContext(context.Background(), ids)
+}
+
+// GetByIdsContext gets the resources with any of several IDs, aborting if ctx is done
+func (r *Repository[T]) GetByIdsContext(ctx context.Context, ids []int64) ([]T, []int64, error) {
+	var records []T
+
+	if len(ids) > 0 {
+		if err := r.s.getByIds(ctx, r.res.table, ids, &records); err != nil {
+			return nil, nil, NewError(err)
+		}
+	}
+
+	byId := make(map[int64]T, len(records))
+
+	for _, record := range records {
+		byId[r.res.idOf(&record)] = record
+	}
+
+	found := make([]T, 0, len(ids))
+	var missing []int64
+
+	for _, id := range ids {
+		if record, ok := byId[id]; ok {
+			found = append(found, record)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	return found, missing, r.s.missing(missing)
+}
+
+// Delete soft-deletes a resource, hiding it from reads until it is restored
+func (r *Repository[T]) Delete(id int64) error {
+	return r.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext soft-deletes a resource, aborting if ctx is done
+func (r *Repository[T]) DeleteContext(ctx context.Context, id int64) error {
+	return NewError(r.s.delete(ctx, r.res.table, id))
+}
+
+// Restore restores a soft-deleted resource
+func (r *Repository[T]) Restore(id int64) error {
+	return r.RestoreContext(context.Background(), id)
+}
+
+// RestoreContext restores a soft-deleted resource, aborting if ctx is done
+func (r *Repository[T]) RestoreContext(ctx context.Context, id int64) error {
+	return NewError(r.s.restore(ctx, r.res.table, id))
+}
+
+// Purge permanently deletes a resource, whether it is soft-deleted or not
+func (r *Repository[T]) Purge(id int64) error {
+	return r.PurgeContext(context.Background(), id)
+}
+
+// PurgeContext permanently deletes a resource, aborting if ctx is done
+func (r *Repository[T]) PurgeContext(ctx context.Context, id int64) error {
+	return NewError(r.s.purge(ctx, r.res.table, id))
+}
+
+// List returns a page of resources, ordered by ID unless the options specify a sort
+func (r *Repository[T]) List(opts ListOptions) (*Page[T], error) {
+	return r.ListContext(context.Background(), opts)
+}
+
+// ListContext returns a page of resources, aborting if ctx is done
+func (r *Repository[T]) ListContext(ctx context.Context, opts ListOptions) (*Page[T], error) {
+	items := []T{}
+	next, total, err := r.s.list(ctx, r.res.schema, opts, &items)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return &Page[T]{Items: items, NextCursor: next, Total: total}, nil
+}
+
+// resource describes how a resource type is stored, as derived from its struct tags
+type resource struct {
+	table  string
+	schema *schema
+
+	// the columns written when creating a resource
+	columns []string
+
+	// the fields that can be updated
+	updatable []reflect.StructField
+
+	// the index of the field of the id column
+	id []int
+}
+
+// the columns that the store manages for every resource
+var managedColumns = append([]string{"deleted_at"}, generatedColumns...)
+
+// resources caches the resource of each type
+var resources sync.Map
+
+// resourceOf returns the resource of a struct type
+func resourceOf(t reflect.Type) *resource {
+	if res, ok := resources.Load(t); ok {
+		return res.(*resource)
+	}
+
+	res, _ := resources.LoadOrStore(t, newResource(t))
+
+	return res.(*resource)
+}
+
+func newResource(t reflect.Type) *resource {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("data: resource type %s is not a struct", t))
+	}
+
+	table := snakeCase(t.Name())
+
+	if namer, ok := reflect.New(t).Interface().(TableNamer); ok {
+		table = namer.TableName()
+	}
+
+	res := &resource{
+		table:  table,
+		schema: newSchema(table, reflect.Zero(t).Interface(), "id"),
+	}
+
+	for _, col := range managedColumns {
+		if _, ok := res.schema.fields[col]; !ok {
+			panic(fmt.Sprintf("data: resource type %s has no field for the %s column", t, col))
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		col := f.Tag.Get("db")
+
+		if col == "id" {
+			res.id = f.Index
+		}
+
+		if col == "" || col == "-" || includes(managedColumns, col) {
+			continue
+		}
+
+		res.columns = append(res.columns, col)
+
+		if f.Tag.Get("validate") != "" {
+			res.updatable = append(res.updatable, f)
+		}
+	}
+
+	return res
+}
+
+// idOf returns the id of a record
+func (res *resource) idOf(record interface{}) int64 {
+	return reflect.Indirect(reflect.ValueOf(record)).FieldByIndex(res.id).Int()
+}
+
+// sets maps the updatable fields of a record to their columns
+func (res *resource) sets(record interface{}) []set {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	sets := make([]set, len(res.updatable))
+
+	for i, f := range res.updatable {
+		sets[i] = set{f.Name, f.Tag.Get("db"), v.FieldByIndex(f.Index).Interface()}
+	}
+
+	return sets
+}
+
+// snakeCase converts a Go type name such as GroupUser to a table name such as group_user
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				b.WriteRune('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
@@ -1,15 +1,22 @@
 package data
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/gocraft/dbr/v2"
 	"github.com/gocraft/dbr/v2/dialect"
 	"gopkg.in/go-playground/validator.v9"
+	"reflect"
+	"strings"
 )
 
 type Store struct {
-	db       *dbr.Session
+	db       dbr.SessionRunner
+	sess     *dbr.Session
+	tx       *dbr.Tx
+	depth    int
+	strict   bool
 	validate *validator.Validate
 }
 
@@ -22,144 +29,332 @@ func NewStore(d *sql.DB, maxConn int) (*Store, error) {
 
 	conn.SetMaxOpenConns(maxConn)
 	sess := conn.NewSession(nil)
-	_, err := sess.Begin()
+	err := d.Ping()
 
 	if err != nil {
-		return nil, errors.New("unable to create data store")
+		return nil, &Error{Err: err, Msg: "unable to create data store", Kind: ErrConnection}
 	}
 
 	v := validator.New()
 
+	// validation errors name fields as they are named in JSON
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	_ = v.RegisterValidation("username", isUsername)
+
 	return &Store{
 		db:       sess,
+		sess:     sess,
 		validate: v,
 	}, nil
 }
 
-// CreateUser creates a new user
-func (s *Store) CreateUser(u *User) (*User, error) {
-	if err := s.validate.Struct(u); err != nil {
-		return nil, NewError(err)
-	}
+// Strict returns a copy of the store whose lookups by ID fail with ErrNotFound when the resource does not exist,
+// rather than returning a nil resource and a nil error
+func (s *Store) Strict() *Store {
+	strict := *s
+	strict.strict = true
 
-	columns := []string{"first_name", "last_name",}
-	id, err := s.create("user", u, columns)
+	return &strict
+}
 
-	if err != nil {
-		return nil, NewError(err)
+// notFound is the error of a lookup by ID that found nothing, which is only an error in strict mode
+func (s *Store) notFound() error {
+	if s.strict {
+		return ErrNotFound
 	}
 
-	if id != nil {
-		u.Id = id.(int64)
+	return nil
+}
+
+// missing is the error of a lookup by IDs that did not find some of them, which is only an error in strict mode
+func (s *Store) missing(ids []int64) error {
+	if s.strict && len(ids) > 0 {
+		return &MissingIdsError{Ids: ids}
 	}
 
-	return u, nil
+	return nil
+}
+
+// the resources of users and groups, which are derived from their struct tags
+var userResource = resourceOf(reflect.TypeOf(User{}))
+var groupResource = resourceOf(reflect.TypeOf(Group{}))
+
+// CreateUser creates a new user, setting its Id and timestamps
+func (s *Store) CreateUser(u *User) (*User, error) {
+	return s.CreateUserContext(context.Background(), u)
+}
+
+// CreateUserContext creates a new user, aborting if ctx is done
+func (s *Store) CreateUserContext(ctx context.Context, u *User) (*User, error) {
+	return NewRepository[User](s).CreateContext(ctx, u)
 }
 
 // UpdateUser updates an existing user.
 // The variadic "fields" arg should contain the field names that should be updated
 func (s *Store) UpdateUser(id int64, u *User, fields ...string) error {
-	if err := s.validate.StructPartial(u, fields...); err != nil {
-		return NewError(err)
-	}
+	return s.UpdateUserContext(context.Background(), id, u, fields...)
+}
 
-	err := s.update("user", id, fields,
-		set{"FirstName", "first_name", u.FirstName},
-		set{"LastName", "last_name", u.LastName},
-	)
+// UpdateUserContext updates an existing user, aborting if ctx is done.
+// The variadic "fields" arg should contain the field names that should be updated
+func (s *Store) UpdateUserContext(ctx context.Context, id int64, u *User, fields ...string) error {
+	return NewRepository[User](s).UpdateContext(ctx, id, u, fields...)
+}
 
-	return NewError(err)
+// UpdateUserIfVersion updates an existing user like UpdateUser, but only if the user is still at the expected version.
+// If the user has been changed since, a *VersionConflictError holding its current version is returned
+func (s *Store) UpdateUserIfVersion(id int64, version int64, u *User, fields ...string) error {
+	return s.UpdateUserIfVersionContext(context.Background(), id, version, u, fields...)
 }
 
-// GetUserById gets a user by ID
+// UpdateUserIfVersionContext updates an existing user if it is at the expected version, aborting if ctx is done
+func (s *Store) UpdateUserIfVersionContext(ctx context.Context, id int64, version int64, u *User, fields ...string) error {
+	return NewRepository[User](s).UpdateIfVersionContext(ctx, id, version, u, fields...)
+}
+
+// GetUserById gets a user by ID. It returns a nil user if there is none, unless the store is Strict
 func (s *Store) GetUserById(id int64) (*User, error) {
+	return s.GetUserByIdContext(context.Background(), id)
+}
+
+// GetUserByIdContext gets a user by ID, aborting if ctx is done
+func (s *Store) GetUserByIdContext(ctx context.Context, id int64) (*User, error) {
+	return NewRepository[User](s).GetByIdContext(ctx, id)
+}
+
+// GetUsersByIds gets the users with any of several IDs in one query. The users are in the order of ids,
+// and the IDs without a user are returned in that order too. A Strict store also returns a *MissingIdsError for them
+func (s *Store) GetUsersByIds(ids []int64) ([]User, []int64, error) {
+	return s.GetUsersByIdsContext(context.Background(), ids)
+}
+
+// GetUsersByIdsContext gets the users with any of several IDs, aborting if ctx is done
+func (s *Store) GetUsersByIdsContext(ctx context.Context, ids []int64) ([]User, []int64, error) {
+	return NewRepository[User](s).GetByIdsContext(ctx, ids)
+}
+
+// GetUserByEmail gets a user by email address, ignoring case.
+// It returns a nil user if there is none, unless the store is Strict
+func (s *Store) GetUserByEmail(email string) (*User, error) {
+	return s.GetUserByEmailContext(context.Background(), email)
+}
+
+// GetUserByEmailContext gets a user by email address, aborting if ctx is done
+func (s *Store) GetUserByEmailContext(ctx context.Context, email string) (*User, error) {
+	return s.getUserWhere(ctx, "lower(email) = lower(?)", email)
+}
+
+// GetUserByUsername gets a user by username, ignoring case.
+// It returns a nil user if there is none, unless the store is Strict
+func (s *Store) GetUserByUsername(username string) (*User, error) {
+	return s.GetUserByUsernameContext(context.Background(), username)
+}
+
+// GetUserByUsernameContext gets a user by username, aborting if ctx is done
+func (s *Store) GetUserByUsernameContext(ctx context.Context, username string) (*User, error) {
+	return s.getUserWhere(ctx, "lower(username) = lower(?)", username)
+}
+
+func (s *Store) getUserWhere(ctx context.Context, query string, value interface{}) (*User, error) {
 	u := &User{}
-	retrieved, count, err := s.getById("user", id, u)
+	retrieved, count, err := s.getWhere(ctx, "user", u, query, value)
 
 	if err != nil {
 		return nil, NewError(err)
 	}
 
 	if count == 0 {
-		return nil, nil
+		return nil, s.notFound()
 	}
 
 	return retrieved.(*User), nil
 }
 
-// DeleteUser deletes a user
-func (s *Store) DeleteUser(id int64) error {
-    err := s.delete("user", id)
-    return NewError(err)
+// DeleteUser soft-deletes a user, hiding it from reads until it is restored.
+// The optional policy decides what happens to the user's memberships, and defaults to DeleteKeep
+func (s *Store) DeleteUser(id int64, policy ...DeletePolicy) error {
+	return s.DeleteUserContext(context.Background(), id, policy...)
 }
 
-// CreateGroup creates a new group
-func (s *Store) CreateGroup(g *Group) (*Group, error) {
-	if err := s.validate.Struct(g); err != nil {
-		return nil, NewError(err)
-	}
+// DeleteUserContext soft-deletes a user, aborting if ctx is done
+func (s *Store) DeleteUserContext(ctx context.Context, id int64, policy ...DeletePolicy) error {
+	err := s.deleteWithPolicy(ctx, "user", "user_id", id, deletePolicy(policy), false, func(tx *Store) error {
+		return tx.delete(ctx, "user", id)
+	})
+
+	return NewError(err)
+}
 
-	columns := []string{"name",}
-	id, err := s.create("group", g, columns)
+// RestoreUser restores a soft-deleted user
+func (s *Store) RestoreUser(id int64) error {
+	return s.RestoreUserContext(context.Background(), id)
+}
+
+// RestoreUserContext restores a soft-deleted user, aborting if ctx is done
+func (s *Store) RestoreUserContext(ctx context.Context, id int64) error {
+	return NewRepository[User](s).RestoreContext(ctx, id)
+}
+
+// PurgeUser permanently deletes a user, whether it is soft-deleted or not.
+// The optional policy decides what happens to the user's memberships, and defaults to DeleteRestrict
+func (s *Store) PurgeUser(id int64, policy ...DeletePolicy) error {
+	return s.PurgeUserContext(context.Background(), id, policy...)
+}
+
+// PurgeUserContext permanently deletes a user, aborting if ctx is done
+func (s *Store) PurgeUserContext(ctx context.Context, id int64, policy ...DeletePolicy) error {
+	err := s.deleteWithPolicy(ctx, "user", "user_id", id, deletePolicy(policy), true, func(tx *Store) error {
+		return tx.purge(ctx, "user", id)
+	})
+
+	return NewError(err)
+}
+
+// ListUsers returns a page of users that match the options' filters, ordered by ID unless the options specify a sort
+func (s *Store) ListUsers(opts ListOptions) (*UserList, error) {
+	return s.ListUsersContext(context.Background(), opts)
+}
+
+// ListUsersContext returns a page of users that match the options' filters, aborting if ctx is done
+func (s *Store) ListUsersContext(ctx context.Context, opts ListOptions) (*UserList, error) {
+	page, err := NewRepository[User](s).ListContext(ctx, opts)
 
 	if err != nil {
-		return nil, NewError(err)
+		return nil, err
 	}
 
-	if id != nil {
-		g.Id = id.(int64)
-	}
+	return &UserList{Users: page.Items, NextCursor: page.NextCursor, Total: page.Total}, nil
+}
 
-	return g, nil
+// CreateGroup creates a new group, setting its Id and timestamps
+func (s *Store) CreateGroup(g *Group) (*Group, error) {
+	return s.CreateGroupContext(context.Background(), g)
+}
+
+// CreateGroupContext creates a new group, aborting if ctx is done
+func (s *Store) CreateGroupContext(ctx context.Context, g *Group) (*Group, error) {
+	return NewRepository[Group](s).CreateContext(ctx, g)
 }
 
 // UpdateGroup updates an existing group
 // The variadic "fields" arg should contain the field names that should be updated
 func (s *Store) UpdateGroup(id int64, g *Group, fields ...string) error {
-	if err := s.validate.StructPartial(g, fields...); err != nil {
-		return NewError(err)
-	}
+	return s.UpdateGroupContext(context.Background(), id, g, fields...)
+}
 
-	err := s.update("group", id, fields,
-		set{"Name", "name", g.Name},
-	)
+// UpdateGroupContext updates an existing group, aborting if ctx is done.
+// The variadic "fields" arg should contain the field names that should be updated
+func (s *Store) UpdateGroupContext(ctx context.Context, id int64, g *Group, fields ...string) error {
+	return NewRepository[Group](s).UpdateContext(ctx, id, g, fields...)
+}
 
-	return NewError(err)
+// UpdateGroupIfVersion updates an existing group like UpdateGroup, but only if the group is still at the expected version.
+// If the group has been changed since, a *VersionConflictError holding its current version is returned
+func (s *Store) UpdateGroupIfVersion(id int64, version int64, g *Group, fields ...string) error {
+	return s.UpdateGroupIfVersionContext(context.Background(), id, version, g, fields...)
+}
+
+// UpdateGroupIfVersionContext updates an existing group if it is at the expected version, aborting if ctx is done
+func (s *Store) UpdateGroupIfVersionContext(ctx context.Context, id int64, version int64, g *Group, fields ...string) error {
+	return NewRepository[Group](s).UpdateIfVersionContext(ctx, id, version, g, fields...)
 }
 
-// GetGroupById gets a group by ID
+// GetGroupById gets a group by ID. It returns a nil group if there is none, unless the store is Strict
 func (s *Store) GetGroupById(id int64) (*Group, error) {
-	g := &Group{}
-	retrieved, count, err := s.getById("group", id, g)
+	return s.GetGroupByIdContext(context.Background(), id)
+}
 
-	if err != nil {
-		return nil, NewError(err)
-	}
+// GetGroupByIdContext gets a group by ID, aborting if ctx is done
+func (s *Store) GetGroupByIdContext(ctx context.Context, id int64) (*Group, error) {
+	return NewRepository[Group](s).GetByIdContext(ctx, id)
+}
 
-	if count == 0 {
-		return nil, nil
-	}
+// GetGroupsByIds gets the groups with any of several IDs in one query. The groups are in the order of ids,
+// and the IDs without a group are returned in that order too. A Strict store also returns a *MissingIdsError for them
+func (s *Store) GetGroupsByIds(ids []int64) ([]Group, []int64, error) {
+	return s.GetGroupsByIdsContext(context.Background(), ids)
+}
+
+// GetGroupsByIdsContext gets the groups with any of several IDs, aborting if ctx is done
+func (s *Store) GetGroupsByIdsContext(ctx context.Context, ids []int64) ([]Group, []int64, error) {
+	return NewRepository[Group](s).GetByIdsContext(ctx, ids)
+}
+
+// DeleteGroup soft-deletes a group, hiding it from reads until it is restored.
+// The optional policy decides what happens to the group's memberships, and defaults to DeleteKeep
+func (s *Store) DeleteGroup(id int64, policy ...DeletePolicy) error {
+	return s.DeleteGroupContext(context.Background(), id, policy...)
+}
+
+// DeleteGroupContext soft-deletes a group, aborting if ctx is done
+func (s *Store) DeleteGroupContext(ctx context.Context, id int64, policy ...DeletePolicy) error {
+	err := s.deleteWithPolicy(ctx, "group", "group_id", id, deletePolicy(policy), false, func(tx *Store) error {
+		return tx.delete(ctx, "group", id)
+	})
+
+	return NewError(err)
+}
+
+// RestoreGroup restores a soft-deleted group
+func (s *Store) RestoreGroup(id int64) error {
+	return s.RestoreGroupContext(context.Background(), id)
+}
+
+// RestoreGroupContext restores a soft-deleted group, aborting if ctx is done
+func (s *Store) RestoreGroupContext(ctx context.Context, id int64) error {
+	return NewRepository[Group](s).RestoreContext(ctx, id)
+}
 
-	return retrieved.(*Group), err
+// PurgeGroup permanently deletes a group, whether it is soft-deleted or not.
+// The optional policy decides what happens to the group's memberships, and defaults to DeleteRestrict
+func (s *Store) PurgeGroup(id int64, policy ...DeletePolicy) error {
+	return s.PurgeGroupContext(context.Background(), id, policy...)
 }
 
-// DeleteUser deletes a group
-func (s *Store) DeleteGroup(id int64) error {
-	err := s.delete("group", id)
+// PurgeGroupContext permanently deletes a group, aborting if ctx is done
+func (s *Store) PurgeGroupContext(ctx context.Context, id int64, policy ...DeletePolicy) error {
+	err := s.deleteWithPolicy(ctx, "group", "group_id", id, deletePolicy(policy), true, func(tx *Store) error {
+		return tx.purge(ctx, "group", id)
+	})
+
 	return NewError(err)
 }
 
+// ListGroups returns a page of groups that match the options' filters, ordered by ID unless the options specify a sort
+func (s *Store) ListGroups(opts ListOptions) (*GroupList, error) {
+	return s.ListGroupsContext(context.Background(), opts)
+}
+
+// ListGroupsContext returns a page of groups that match the options' filters, aborting if ctx is done
+func (s *Store) ListGroupsContext(ctx context.Context, opts ListOptions) (*GroupList, error) {
+	page, err := NewRepository[Group](s).ListContext(ctx, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupList{Groups: page.Items, NextCursor: page.NextCursor, Total: page.Total}, nil
+}
+
 // GetUsersByGroupId returns an array of users that belong to a group
 func (s *Store) GetUsersByGroupId(groupId int64) ([]User, error) {
+	return s.GetUsersByGroupIdContext(context.Background(), groupId)
+}
+
+// GetUsersByGroupIdContext returns an array of users that belong to a group, aborting if ctx is done
+func (s *Store) GetUsersByGroupIdContext(ctx context.Context, groupId int64) ([]User, error) {
 	var users []User
 
-	_, err := s.selectJunction(s.db, groupId, junction{
-		table1: "user",
-		table2: "group",
-		junctionTable: "group_user",
-		junctionFk1: "user_id",
-		junctionFk2: "group_id",
-	}).Load(&users)
+	_, err := s.selectJunction(s.db, groupId, usersByGroup).LoadContext(ctx, &users)
 
 	if err != nil {
 		return nil, NewError(err)
@@ -170,15 +365,14 @@ func (s *Store) GetUsersByGroupId(groupId int64) ([]User, error) {
 
 // GetUsersByGroupId returns an array of groups that contain a user
 func (s *Store) GetGroupsByUserId(userId int64) ([]Group, error) {
+	return s.GetGroupsByUserIdContext(context.Background(), userId)
+}
+
+// GetGroupsByUserIdContext returns an array of groups that contain a user, aborting if ctx is done
+func (s *Store) GetGroupsByUserIdContext(ctx context.Context, userId int64) ([]Group, error) {
     var groups []Group
 
-	_, err := s.selectJunction(s.db, userId, junction{
-		table1: "group",
-		table2: "user",
-		junctionTable: "group_user",
-		junctionFk1: "group_id",
-		junctionFk2: "user_id",
-	}).Load(&groups)
+	_, err := s.selectJunction(s.db, userId, groupsByUser).LoadContext(ctx, &groups)
 
 	if err != nil {
 		return nil, NewError(err)
@@ -187,23 +381,418 @@ func (s *Store) GetGroupsByUserId(userId int64) ([]Group, error) {
 	return groups, nil
 }
 
-// LinkGroupToUser links a group to a user
-func (s *Store) LinkGroupToUser(groupId int64, userId int64) error {
-	_, err := s.db.
-		InsertInto("group_user").
-		Pair("group_id", groupId).
-		Pair("user_id", userId).
-		Exec()
+// GetUsersByGroupIds returns the users that belong to each of several groups in one query.
+// Every group ID is a key of the map, with no users if the group has none or does not exist,
+// and the IDs without a group are returned in their order. A Strict store also returns a *MissingIdsError for them
+func (s *Store) GetUsersByGroupIds(groupIds []int64) (map[int64][]User, []int64, error) {
+	return s.GetUsersByGroupIdsContext(context.Background(), groupIds)
+}
+
+// GetUsersByGroupIdsContext returns the users that belong to each of several groups, aborting if ctx is done
+func (s *Store) GetUsersByGroupIdsContext(ctx context.Context, groupIds []int64) (map[int64][]User, []int64, error) {
+	var rows []struct {
+		User
+		LookupId int64 `db:"lookup_id"`
+	}
+
+	users := make(map[int64][]User, len(groupIds))
+
+	for _, id := range groupIds {
+		users[id] = []User{}
+	}
+
+	if len(groupIds) == 0 {
+		return users, nil, nil
+	}
+
+	missing, err := s.missingIds(ctx, "group", groupIds)
+
+	if err != nil {
+		return nil, nil, NewError(err)
+	}
+
+	_, err = s.selectJunctionAny(s.db, groupIds, usersByGroup).LoadContext(ctx, &rows)
+
+	if err != nil {
+		return nil, nil, NewError(err)
+	}
+
+	for _, row := range rows {
+		users[row.LookupId] = append(users[row.LookupId], row.User)
+	}
+
+	return users, missing, s.missing(missing)
+}
+
+// GetGroupsByUserIds returns the groups that contain each of several users in one query.
+// Every user ID is a key of the map, with no groups if the user has none or does not exist,
+// and the IDs without a user are returned in their order. A Strict store also returns a *MissingIdsError for them
+func (s *Store) GetGroupsByUserIds(userIds []int64) (map[int64][]Group, []int64, error) {
+	return s.GetGroupsByUserIdsContext(context.Background(), userIds)
+}
+
+// GetGroupsByUserIdsContext returns the groups that contain each of several users, aborting if ctx is done
+func (s *Store) GetGroupsByUserIdsContext(ctx context.Context, userIds []int64) (map[int64][]Group, []int64, error) {
+	var rows []struct {
+		Group
+		LookupId int64 `db:"lookup_id"`
+	}
+
+	groups := make(map[int64][]Group, len(userIds))
+
+	for _, id := range userIds {
+		groups[id] = []Group{}
+	}
+
+	if len(userIds) == 0 {
+		return groups, nil, nil
+	}
+
+	missing, err := s.missingIds(ctx, "user", userIds)
+
+	if err != nil {
+		return nil, nil, NewError(err)
+	}
+
+	_, err = s.selectJunctionAny(s.db, userIds, groupsByUser).LoadContext(ctx, &rows)
+
+	if err != nil {
+		return nil, nil, NewError(err)
+	}
+
+	for _, row := range rows {
+		groups[row.LookupId] = append(groups[row.LookupId], row.Group)
+	}
+
+	return groups, missing, s.missing(missing)
+}
+
+// ListUsersByGroupId returns a page of the users that belong to a group
+func (s *Store) ListUsersByGroupId(groupId int64, opts ListOptions) (*UserList, error) {
+	return s.ListUsersByGroupIdContext(context.Background(), groupId, opts)
+}
+
+// ListUsersByGroupIdContext returns a page of the users that belong to a group, aborting if ctx is done
+func (s *Store) ListUsersByGroupIdContext(ctx context.Context, groupId int64, opts ListOptions) (*UserList, error) {
+	users := []User{}
+	stmt := s.selectJunction(s.db, groupId, usersByGroup)
+	applySince(stmt, "group_user.linked_at", opts)
+	next, total, err := s.loadPage(ctx, stmt, userSchema, opts, &users)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return &UserList{Users: users, NextCursor: next, Total: total}, nil
+}
+
+// ListGroupsByUserId returns a page of the groups that contain a user
+func (s *Store) ListGroupsByUserId(userId int64, opts ListOptions) (*GroupList, error) {
+	return s.ListGroupsByUserIdContext(context.Background(), userId, opts)
+}
+
+// ListGroupsByUserIdContext returns a page of the groups that contain a user, aborting if ctx is done
+func (s *Store) ListGroupsByUserIdContext(ctx context.Context, userId int64, opts ListOptions) (*GroupList, error) {
+	groups := []Group{}
+	stmt := s.selectJunction(s.db, userId, groupsByUser)
+	applySince(stmt, "group_user.linked_at", opts)
+	next, total, err := s.loadPage(ctx, stmt, groupSchema, opts, &groups)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return &GroupList{Groups: groups, NextCursor: next, Total: total}, nil
+}
+
+// ListUsersByGroupIds returns a page of the users that belong to each of several groups in one query.
+// The options apply to every page, and Count is ignored.
+// Every group ID is a key of the map, with no users if the group has none or does not exist,
+// and the IDs without a group are returned like GetUsersByGroupIds does
+func (s *Store) ListUsersByGroupIds(groupIds []int64, opts ListOptions) (map[int64]*UserList, []int64, error) {
+	return s.ListUsersByGroupIdsContext(context.Background(), groupIds, opts)
+}
+
+// ListUsersByGroupIdsContext returns a page of the users of each of several groups, aborting if ctx is done
+func (s *Store) ListUsersByGroupIdsContext(ctx context.Context, groupIds []int64, opts ListOptions) (map[int64]*UserList, []int64, error) {
+	var rows []struct {
+		User
+		LookupId int64 `db:"lookup_id"`
+	}
+
+	lists := make(map[int64]*UserList, len(groupIds))
+
+	for _, id := range groupIds {
+		lists[id] = &UserList{Users: []User{}}
+	}
+
+	if len(groupIds) == 0 {
+		return lists, nil, nil
+	}
+
+	missing, err := s.missingIds(ctx, "group", groupIds)
+
+	if err != nil {
+		return nil, nil, NewError(err)
+	}
+
+	stmt := s.selectJunctionAny(s.db, groupIds, usersByGroup)
+	applySince(stmt, "group_user.linked_at", opts)
+	next, err := s.loadPages(ctx, stmt, userSchema, opts, &rows)
+
+	if err != nil {
+		return nil, nil, NewError(err)
+	}
+
+	for _, row := range rows {
+		lists[row.LookupId].Users = append(lists[row.LookupId].Users, row.User)
+	}
+
+	for id, cursor := range next {
+		lists[id].NextCursor = cursor
+	}
+
+	return lists, missing, s.missing(missing)
+}
+
+// ListGroupsByUserIds returns a page of the groups that contain each of several users in one query.
+// The options apply to every page, and Count is ignored.
+// Every user ID is a key of the map, with no groups if the user has none or does not exist,
+// and the IDs without a user are returned like GetGroupsByUserIds does
+func (s *Store) ListGroupsByUserIds(userIds []int64, opts ListOptions) (map[int64]*GroupList, []int64, error) {
+	return s.ListGroupsByUserIdsContext(context.Background(), userIds, opts)
+}
+
+// ListGroupsByUserIdsContext returns a page of the groups of each of several users, aborting if ctx is done
+func (s *Store) ListGroupsByUserIdsContext(ctx context.Context, userIds []int64, opts ListOptions) (map[int64]*GroupList, []int64, error) {
+	var rows []struct {
+		Group
+		LookupId int64 `db:"lookup_id"`
+	}
+
+	lists := make(map[int64]*GroupList, len(userIds))
+
+	for _, id := range userIds {
+		lists[id] = &GroupList{Groups: []Group{}}
+	}
+
+	if len(userIds) == 0 {
+		return lists, nil, nil
+	}
+
+	missing, err := s.missingIds(ctx, "user", userIds)
+
+	if err != nil {
+		return nil, nil, NewError(err)
+	}
+
+	stmt := s.selectJunctionAny(s.db, userIds, groupsByUser)
+	applySince(stmt, "group_user.linked_at", opts)
+	next, err := s.loadPages(ctx, stmt, groupSchema, opts, &rows)
+
+	if err != nil {
+		return nil, nil, NewError(err)
+	}
+
+	for _, row := range rows {
+		lists[row.LookupId].Groups = append(lists[row.LookupId].Groups, row.Group)
+	}
+
+	for id, cursor := range next {
+		lists[id].NextCursor = cursor
+	}
+
+	return lists, missing, s.missing(missing)
+}
+
+// ListMemberships returns a page of the links between groups and users, ordered by group ID and user ID unless the options specify a sort
+func (s *Store) ListMemberships(opts ListOptions) (*MembershipList, error) {
+	return s.ListMembershipsContext(context.Background(), opts)
+}
+
+// ListMembershipsContext returns a page of the links between groups and users, aborting if ctx is done
+func (s *Store) ListMembershipsContext(ctx context.Context, opts ListOptions) (*MembershipList, error) {
+	memberships := []Membership{}
+
+	stmt := s.db.
+		Select("group_user.*").
+		From("group_user").
+		Where(visibleMembership)
+
+	applySince(stmt, "group_user.linked_at", opts)
+	next, total, err := s.loadPage(ctx, stmt, membershipSchema, opts, &memberships)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return &MembershipList{Memberships: memberships, NextCursor: next, Total: total}, nil
+}
+
+// LinkGroupToUser links a group to a user.
+// The optional role is the user's role in the group, and defaults to RoleMember
+func (s *Store) LinkGroupToUser(groupId int64, userId int64, role ...Role) error {
+	return s.LinkGroupToUserContext(context.Background(), groupId, userId, role...)
+}
+
+// LinkGroupToUserContext links a group to a user, aborting if ctx is done
+func (s *Store) LinkGroupToUserContext(ctx context.Context, groupId int64, userId int64, role ...Role) error {
+	r := memberRole(role)
+
+	if err := s.validateRole(r); err != nil {
+		return err
+	}
+
+	// inserting from a select of the group and user rather than relying on foreign keys also rejects soft-deleted ones
+	result, err := s.db.
+		InsertBySql(
+			`insert into group_user (group_id, user_id, role) select g.id, u.id, ? from "group" g, "user" u
+			where g.id = ? and u.id = ? and g.deleted_at is null and u.deleted_at is null`,
+			r, groupId, userId,
+		).
+		ExecContext(ctx)
+
+	err = affectedOne(result, err)
+
+	if errors.Is(err, ErrNotFound) {
+		return errGroupOrUserDNE
+	}
 
 	return NewError(err)
 }
 
-// UnlinkGroupFromUser unlinks a group from a user
+// CreateMembership links the group of a membership to its user with the membership's role and attributes,
+// which are written together so that a failure leaves no membership behind. The role defaults to RoleMember.
+// It returns the stored membership
+func (s *Store) CreateMembership(m *Membership) (*Membership, error) {
+	return s.CreateMembershipContext(context.Background(), m)
+}
+
+// CreateMembershipContext links a group to a user with a role and attributes, aborting if ctx is done
+func (s *Store) CreateMembershipContext(ctx context.Context, m *Membership) (*Membership, error) {
+	r := memberRole([]Role{m.Role})
+
+	if err := s.validateRole(r); err != nil {
+		return nil, err
+	}
+
+	var created []Membership
+
+	err := s.db.
+		InsertBySql(
+			`insert into group_user (group_id, user_id, role, attributes) select g.id, u.id, ?, ? from "group" g, "user" u
+			where g.id = ? and u.id = ? and g.deleted_at is null and u.deleted_at is null returning *`,
+			r, m.Attributes, m.GroupId, m.UserId,
+		).
+		LoadContext(ctx, &created)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	if len(created) == 0 {
+		return nil, errGroupOrUserDNE
+	}
+
+	return &created[0], nil
+}
+
+// GetMembership gets the membership of a user in a group.
+// It returns a nil membership if the user is not in the group, unless the store is Strict
+func (s *Store) GetMembership(groupId int64, userId int64) (*Membership, error) {
+	return s.GetMembershipContext(context.Background(), groupId, userId)
+}
+
+// GetMembershipContext gets the membership of a user in a group, aborting if ctx is done
+func (s *Store) GetMembershipContext(ctx context.Context, groupId int64, userId int64) (*Membership, error) {
+	m := &Membership{}
+
+	count, err := s.db.
+		Select("group_user.*").
+		From("group_user").
+		Where("group_id = ? and user_id = ?", groupId, userId).
+		Where(visibleMembership).
+		LoadContext(ctx, m)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	if count == 0 {
+		return nil, s.notFound()
+	}
+
+	return m, nil
+}
+
+// SetMemberRole changes the role of a user in a group
+func (s *Store) SetMemberRole(groupId int64, userId int64, role Role) error {
+	return s.SetMemberRoleContext(context.Background(), groupId, userId, role)
+}
+
+// SetMemberRoleContext changes the role of a user in a group, aborting if ctx is done
+func (s *Store) SetMemberRoleContext(ctx context.Context, groupId int64, userId int64, role Role) error {
+	if err := s.validateRole(role); err != nil {
+		return err
+	}
+
+	err := s.updateMembership(ctx, groupId, userId, map[string]interface{}{"role": role})
+
+	return NewError(err)
+}
+
+// SetMemberAttributes replaces the attributes of the membership of a user in a group.
+// Nil attributes clear them
+func (s *Store) SetMemberAttributes(groupId int64, userId int64, attributes Attributes) error {
+	return s.SetMemberAttributesContext(context.Background(), groupId, userId, attributes)
+}
+
+// SetMemberAttributesContext replaces the attributes of a membership, aborting if ctx is done
+func (s *Store) SetMemberAttributesContext(ctx context.Context, groupId int64, userId int64, attributes Attributes) error {
+	err := s.updateMembership(ctx, groupId, userId, map[string]interface{}{"attributes": attributes})
+
+	return NewError(err)
+}
+
+// UpdateMembership changes the role and attributes of a user in a group together.
+// The variadic "fields" arg should contain the field names that should be updated, which are Role and Attributes
+func (s *Store) UpdateMembership(groupId int64, userId int64, m *Membership, fields ...string) error {
+	return s.UpdateMembershipContext(context.Background(), groupId, userId, m, fields...)
+}
+
+// UpdateMembershipContext changes the role and attributes of a membership together, aborting if ctx is done
+func (s *Store) UpdateMembershipContext(ctx context.Context, groupId int64, userId int64, m *Membership, fields ...string) error {
+	values := map[string]interface{}{}
+
+	for _, field := range fields {
+		switch field {
+		case "Role":
+			if err := s.validateRole(m.Role); err != nil {
+				return err
+			}
+
+			values["role"] = m.Role
+		case "Attributes":
+			values["attributes"] = m.Attributes
+		}
+	}
+
+	err := s.updateMembership(ctx, groupId, userId, values)
+
+	return NewError(err)
+}
+
+// UnlinkGroupFromUser unlinks a group from a user. It fails with ErrNotFound if the user is not in the group
 func (s *Store) UnlinkGroupFromUser(groupId int64, userId int64) error {
-	_, err := s.db.
+	return s.UnlinkGroupFromUserContext(context.Background(), groupId, userId)
+}
+
+// UnlinkGroupFromUserContext unlinks a group from a user, aborting if ctx is done
+func (s *Store) UnlinkGroupFromUserContext(ctx context.Context, groupId int64, userId int64) error {
+	result, err := s.db.
 		DeleteFrom("group_user").
 		Where("group_id = ? and user_id = ?", groupId, userId).
-		Exec()
+		ExecContext(ctx)
 
-	return NewError(err)
+	return NewError(affectedOne(result, err))
 }
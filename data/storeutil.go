@@ -1,9 +1,17 @@
 package data
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/gocraft/dbr/v2"
+	"github.com/lib/pq"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 )
 
 type junction struct {
@@ -16,15 +24,55 @@ type junction struct {
 	junctionFk2   string
 }
 
-func (s *Store) selectJunction(db *dbr.Session, lookupId interface{}, j junction) *dbr.SelectStmt {
-	if j.table1Pk == "" {
-		j.table1Pk = "id"
-	}
+var usersByGroup = junction{
+	table1:        "user",
+	table2:        "group",
+	junctionTable: "group_user",
+	junctionFk1:   "user_id",
+	junctionFk2:   "group_id",
+}
 
-	if j.table2Pk == "" {
-		j.table2Pk = "id"
-	}
+var groupsByUser = junction{
+	table1:        "group",
+	table2:        "user",
+	junctionTable: "group_user",
+	junctionFk1:   "group_id",
+	junctionFk2:   "user_id",
+}
+
+func (s *Store) selectJunction(db dbr.SessionRunner, lookupId interface{}, j junction) *dbr.SelectStmt {
+	j = j.withDefaults()
+
+	return s.joinJunction(db, j).
+		Where(fmt.Sprintf("%s.%s = ?", quotes(j.table2), j.table2Pk), lookupId)
+}
 
+// selectJunctionIn is like selectJunction, but looks up the rows linked to any of the ids selected by a subquery.
+// Rows linked to several of the ids are selected once
+func (s *Store) selectJunctionIn(db dbr.SessionRunner, lookupIds dbr.Builder, j junction) *dbr.SelectStmt {
+	j = j.withDefaults()
+
+	return s.joinJunction(db, j).
+		Distinct().
+		Where(fmt.Sprintf("%s.%s in (?)", quotes(j.table2), j.table2Pk), lookupIds)
+}
+
+// selectJunctionAny is like selectJunction, but looks up the rows linked to any of several ids in one query.
+// Each row carries the id that it is linked to as lookup_id, so rows linked to several of the ids are selected once per id
+func (s *Store) selectJunctionAny(db dbr.SessionRunner, lookupIds []int64, j junction) *dbr.SelectStmt {
+	j = j.withDefaults()
+	lookupId := fmt.Sprintf("%s.%s", quotes(j.table2), j.table2Pk)
+
+	stmt := s.joinJunction(db, j).
+		Where(lookupId+" = any(?::bigint[])", pq.Array(lookupIds)).
+		OrderBy(fmt.Sprintf("%s.%s", quotes(j.table1), j.table1Pk))
+
+	stmt.Column = append(stmt.Column, lookupId+" as lookup_id")
+
+	return stmt
+}
+
+func (s *Store) joinJunction(db dbr.SessionRunner, j junction) *dbr.SelectStmt {
 	// wrapping table names in quotes prevents errors when tables/columns are named after reserved words
 	return db.
 		Select(quotes(j.table1)+".*").
@@ -37,62 +85,175 @@ func (s *Store) selectJunction(db *dbr.Session, lookupId interface{}, j junction
 			j.table2,
 			fmt.Sprintf("%s.%s = %s.%s", quotes(j.table2), j.table2Pk, j.junctionTable, j.junctionFk2),
 		).
-		Where(fmt.Sprintf("%s.%s = ?", quotes(j.table2), j.table2Pk), lookupId)
+		Where(fmt.Sprintf("%s.deleted_at is null", quotes(j.table1))).
+		Where(fmt.Sprintf("%s.deleted_at is null", quotes(j.table2)))
+}
+
+// withDefaults fills in the primary keys of a junction's tables, which default to "id"
+func (j junction) withDefaults() junction {
+	if j.table1Pk == "" {
+		j.table1Pk = "id"
+	}
+
+	if j.table2Pk == "" {
+		j.table2Pk = "id"
+	}
+
+	return j
+}
+
+// the condition that hides the group_user rows of soft-deleted users and groups along with them
+const visibleMembership = `group_user.user_id in (select id from "user" where deleted_at is null) and
+	group_user.group_id in (select id from "group" where deleted_at is null)`
+
+// updateMembership sets columns of the membership of a user in a group
+func (s *Store) updateMembership(ctx context.Context, groupId int64, userId int64, values map[string]interface{}) error {
+	result, err := s.db.
+		Update("group_user").
+		SetMap(values).
+		Where("group_id = ? and user_id = ?", groupId, userId).
+		Where(visibleMembership).
+		ExecContext(ctx)
+
+	return affectedOne(result, err)
+}
+
+// memberRole returns the optional role of a membership, which defaults to RoleMember
+func memberRole(role []Role) Role {
+	if len(role) == 0 || role[0] == "" {
+		return RoleMember
+	}
+
+	return role[0]
+}
+
+// validateRole checks that a role is one of the known roles
+func (s *Store) validateRole(role Role) error {
+	return NewError(s.validate.StructPartial(&Membership{Role: role}, "Role"))
 }
 
 func quotes(s string) string {
 	return fmt.Sprintf("\"%s\"", s)
 }
 
-func (s *Store) create(table string, record interface{}, columns []string) (interface{}, error) {
-	var id interface{}
+// the columns set by the database when a row is created, which are loaded back into the record
+var generatedColumns = []string{"id", "version", "created_at", "updated_at"}
 
-	err := s.db.
+// create inserts a record, loading its generated columns into it
+func (s *Store) create(ctx context.Context, table string, record interface{}, columns []string) error {
+	return s.db.
 		InsertInto(table).
 		Columns(columns...).
 		Record(record).
-		Returning("id").
-		Load(&id)
-
-	if err != nil {
-		return nil, err
-	}
+		Returning(generatedColumns...).
+		LoadContext(ctx, record)
+}
 
-	return id, nil
+func (s *Store) update(ctx context.Context, table string, id interface{}, fields []string, updateSets ...set) error {
+	return s.updateVersion(ctx, table, id, nil, fields, updateSets...)
 }
 
-func (s *Store) update(table string, id interface{}, fields []string, updateSets ...set) error {
+// updateVersion updates a row like update, but only if the row is at the expected version when one is given.
+// Every update moves the row to the next version
+func (s *Store) updateVersion(ctx context.Context, table string, id interface{}, version *int64, fields []string, updateSets ...set) error {
 	setMap := makeSetMap(fields, updateSets...)
 
-	result, err := s.db.
+	if len(setMap) > 0 {
+		setMap["version"] = dbr.Expr("version + 1")
+		setMap["updated_at"] = dbr.Expr("now()")
+	}
+
+	stmt := s.db.
 		Update(table).
 		SetMap(setMap).
-		Where("id = ?", id).
-		Exec()
+		Where("id = ? and deleted_at is null", id)
 
-	if err != nil {
-		return err
+	if version != nil {
+		stmt.Where("version = ?", *version)
 	}
 
-	count, err := result.RowsAffected()
+	result, err := stmt.ExecContext(ctx)
+	err = affectedOne(result, err)
+
+	if version != nil && errors.Is(err, ErrNotFound) {
+		return s.versionConflict(ctx, table, id, *version)
+	}
+
+	return err
+}
+
+// versionConflict explains why a versioned update found no row to update,
+// which is either because the row does not exist or because it is at another version
+func (s *Store) versionConflict(ctx context.Context, table string, id interface{}, expected int64) error {
+	var versions []int64
+
+	_, err := s.db.
+		Select("version").
+		From(quotes(table)).
+		Where("id = ? and deleted_at is null", id).
+		LoadContext(ctx, &versions)
 
 	if err != nil {
 		return err
 	}
 
-	if count == 0 {
-		return errors.New(ErrResourceDNE)
+	if len(versions) == 0 {
+		return ErrNotFound
 	}
 
+	return &VersionConflictError{Expected: expected, Actual: versions[0]}
+}
+
+func (s *Store) getById(ctx context.Context, table string, id interface{}, resource interface{}) (interface{}, int, error) {
+	return s.getWhere(ctx, table, resource, "id = ?", id)
+}
+
+// getByIds loads the rows of a table that are not soft-deleted and have any of ids into resources, which points to a slice
+func (s *Store) getByIds(ctx context.Context, table string, ids []int64, resources interface{}) error {
+	_, err := s.db.
+		Select("*").
+		From(quotes(table)).
+		Where("id = any(?::bigint[])", pq.Array(ids)).
+		Where("deleted_at is null").
+		LoadContext(ctx, resources)
+
 	return err
 }
 
-func (s *Store) getById(table string, id interface{}, resource interface{}) (interface{}, int, error) {
+// missingIds returns the ids that no row of a table that is not soft-deleted has, in the order of ids
+func (s *Store) missingIds(ctx context.Context, table string, ids []int64) ([]int64, error) {
+	var found []int64
+
+	_, err := s.db.
+		Select("id").
+		From(quotes(table)).
+		Where("id = any(?::bigint[])", pq.Array(ids)).
+		Where("deleted_at is null").
+		LoadContext(ctx, &found)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []int64
+
+	for _, id := range ids {
+		if !slices.Contains(found, id) {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing, nil
+}
+
+// getWhere loads the row of a table that is not soft-deleted and matches a condition into resource
+func (s *Store) getWhere(ctx context.Context, table string, resource interface{}, query string, value ...interface{}) (interface{}, int, error) {
 	count, err := s.db.
 		Select("*").
 		From(fmt.Sprintf(`"%s"`, table)).
-		Where("id = ?", id).
-		Load(resource)
+		Where(query, value...).
+		Where("deleted_at is null").
+		LoadContext(ctx, resource)
 
 	if err != nil {
 		return nil, 0, err
@@ -101,9 +262,41 @@ func (s *Store) getById(table string, id interface{}, resource interface{}) (int
 	return resource, count, nil
 }
 
-func (s *Store) delete(table string, id interface{}) error {
-	result, err := s.db.DeleteFrom(table).Where("id = ?", id).Exec()
+// delete soft-deletes a row by setting its deleted_at column, which hides it from reads
+func (s *Store) delete(ctx context.Context, table string, id interface{}) error {
+	result, err := s.db.
+		Update(table).
+		Set("deleted_at", dbr.Expr("now()")).
+		Set("version", dbr.Expr("version + 1")).
+		Set("updated_at", dbr.Expr("now()")).
+		Where("id = ? and deleted_at is null", id).
+		ExecContext(ctx)
+
+	return affectedOne(result, err)
+}
+
+// restore undoes the soft-deletion of a row
+func (s *Store) restore(ctx context.Context, table string, id interface{}) error {
+	result, err := s.db.
+		Update(table).
+		Set("deleted_at", nil).
+		Set("version", dbr.Expr("version + 1")).
+		Set("updated_at", dbr.Expr("now()")).
+		Where("id = ? and deleted_at is not null", id).
+		ExecContext(ctx)
+
+	return affectedOne(result, err)
+}
+
+// purge permanently deletes a row, whether it is soft-deleted or not
+func (s *Store) purge(ctx context.Context, table string, id interface{}) error {
+	result, err := s.db.DeleteFrom(table).Where("id = ?", id).ExecContext(ctx)
+
+	return affectedOne(result, err)
+}
 
+// affectedOne checks that a statement identifying a row by id found the row
+func affectedOne(result sql.Result, err error) error {
 	if err != nil {
 		return err
 	}
@@ -115,10 +308,10 @@ func (s *Store) delete(table string, id interface{}) error {
 	}
 
 	if count == 0 {
-		return errors.New(ErrResourceDNE)
+		return ErrNotFound
 	}
 
-	return err
+	return nil
 }
 
 func includes(strings []string, val string) bool {
@@ -148,3 +341,247 @@ func makeSetMap(fields []string, sets ...set) map[string]interface{} {
 
 	return setMap
 }
+
+// bulkBatchSize is the maximum number of rows written by a single statement of a bulk operation
+const bulkBatchSize = 500
+
+// generated holds the generated columns of a row inserted by createMany
+type generated struct {
+	Id        int64     `db:"id"`
+	Version   int64     `db:"version"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// writeBatches calls write with the bounds of each batch of up to bulkBatchSize of n rows. When the write of a batch
+// fails, each of its rows is written again on its own, so that only the rows that cannot be written carry an error.
+// Every write runs in its own transaction, or savepoint when s is transactional, so that a failed write does not
+// abort the writes after it. write records the results of the rows it writes, and is not called again after a
+// batch fails because ctx is done
+func (s *Store) writeBatches(ctx context.Context, results []BulkResult, write func(tx *Store, start int, end int) error) {
+	for start := 0; start < len(results); start += bulkBatchSize {
+		end := batchEnd(start, len(results))
+
+		err := s.WithTx(ctx, func(tx *Store) error {
+			return write(tx, start, end)
+		})
+
+		if err == nil {
+			continue
+		}
+
+		for i := start; i < end; i++ {
+			if end-start > 1 && ctx.Err() == nil {
+				err = s.WithTx(ctx, func(tx *Store) error {
+					return write(tx, i, i+1)
+				})
+			}
+
+			if err != nil {
+				results[i].Err = NewError(err)
+			}
+		}
+	}
+}
+
+// createMany inserts records in batches of multi-row inserts, setting the version and timestamps of each created record.
+// Records that have an id keep it, and the id sequence of the table is moved past it.
+// The returned results are in the order of the records
+func (s *Store) createMany(ctx context.Context, res *resource, records []interface{}) []BulkResult {
+	results := make([]BulkResult, len(records))
+	var newIds, keptIds []int
+
+	for i, record := range records {
+		if res.idOf(record) == 0 {
+			newIds = append(newIds, i)
+		} else {
+			keptIds = append(keptIds, i)
+		}
+	}
+
+	// the rows of a multi-row insert share its columns, so records that keep their ids are inserted separately
+	s.insertMany(ctx, res.table, res.columns, records, newIds, results, false)
+	s.insertMany(ctx, res.table, append([]string{"id"}, res.columns...), records, keptIds, results, true)
+
+	return results
+}
+
+// insertMany inserts the records at the indexes in batches, recording the outcome of each in results
+func (s *Store) insertMany(ctx context.Context, table string, columns []string, records []interface{}, index []int, results []BulkResult, keepIds bool) {
+	inserted := make([]BulkResult, len(index))
+
+	s.writeBatches(ctx, inserted, func(tx *Store, start int, end int) error {
+		stmt := tx.db.InsertInto(table).Columns(columns...)
+
+		for _, i := range index[start:end] {
+			stmt.Record(records[i])
+		}
+
+		var rows []generated
+
+		if err := stmt.Returning(generatedColumns...).LoadContext(ctx, &rows); err != nil {
+			return err
+		}
+
+		if keepIds {
+			if err := tx.resetSequence(ctx, table); err != nil {
+				return err
+			}
+		}
+
+		for j := start; j < end; j++ {
+			inserted[j].Id = rows[j-start].Id
+			setGenerated(records[index[j]], rows[j-start])
+		}
+
+		return nil
+	})
+
+	for j, i := range index {
+		results[i] = inserted[j]
+	}
+}
+
+// resetSequence moves the id sequence of a table past its largest id, so that ids inserted explicitly are not generated again
+func (s *Store) resetSequence(ctx context.Context, table string) error {
+	var next int64
+	query := fmt.Sprintf("select setval(pg_get_serial_sequence('%s', 'id'), coalesce(max(id), 0) + 1, false) from %s", quotes(table), quotes(table))
+
+	return s.db.SelectBySql(query).LoadOneContext(ctx, &next)
+}
+
+// setGenerated copies the generated id, version and timestamps of a row onto the record it was inserted from
+func setGenerated(record interface{}, row generated) {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	v.FieldByName("Id").SetInt(row.Id)
+	v.FieldByName("Version").SetInt(row.Version)
+	v.FieldByName("CreatedAt").Set(reflect.ValueOf(row.CreatedAt))
+	v.FieldByName("UpdatedAt").Set(reflect.ValueOf(row.UpdatedAt))
+}
+
+// updateMany updates rows in batches, joining each table row to a list of values by id.
+// Each element of rows holds the sets of the row with the id at the same index.
+// The returned results are in the order of the ids
+func (s *Store) updateMany(ctx context.Context, table string, ids []int64, fields []string, rows [][]set) []BulkResult {
+	results := newBulkResults(ids)
+
+	var columns []string
+	var assignments []string
+
+	if len(rows) > 0 {
+		for col := range makeSetMap(fields, rows[0]...) {
+			columns = append(columns, col)
+		}
+	}
+
+	sort.Strings(columns)
+
+	for _, col := range columns {
+		assignments = append(assignments, fmt.Sprintf("%s = v.%s", quotes(col), quotes(col)))
+	}
+
+	s.writeBatches(ctx, results, func(tx *Store, start int, end int) error {
+		if len(columns) == 0 {
+			return dbr.ErrColumnNotSpecified
+		}
+
+		var tuples []string
+		var values []interface{}
+
+		for i := start; i < end; i++ {
+			setMap := makeSetMap(fields, rows[i]...)
+			values = append(values, ids[i])
+
+			for _, col := range columns {
+				values = append(values, setMap[col])
+			}
+
+			tuples = append(tuples, "(?"+strings.Repeat(", ?", len(columns))+")")
+		}
+
+		query := fmt.Sprintf(
+			"update %s as t set %s, version = t.version + 1, updated_at = now() from (values %s) as v(id, %s) where t.id = v.id and t.deleted_at is null returning t.id",
+			quotes(table),
+			strings.Join(assignments, ", "),
+			strings.Join(tuples, ", "),
+			strings.Join(quoteAll(columns), ", "),
+		)
+
+		var updated []int64
+
+		if _, err := tx.db.SelectBySql(query, values...).LoadContext(ctx, &updated); err != nil {
+			return err
+		}
+
+		setAffected(results[start:end], updated)
+
+		return nil
+	})
+
+	return results
+}
+
+// deleteMany soft-deletes rows by id in batches.
+// The returned results are in the order of the ids
+func (s *Store) deleteMany(ctx context.Context, table string, ids []int64) []BulkResult {
+	results := newBulkResults(ids)
+
+	s.writeBatches(ctx, results, func(tx *Store, start int, end int) error {
+		var deleted []int64
+		query := fmt.Sprintf("update %s set deleted_at = now(), version = version + 1, updated_at = now() where id in ? and deleted_at is null returning id", quotes(table))
+
+		if _, err := tx.db.SelectBySql(query, ids[start:end]).LoadContext(ctx, &deleted); err != nil {
+			return err
+		}
+
+		setAffected(results[start:end], deleted)
+
+		return nil
+	})
+
+	return results
+}
+
+// newBulkResults returns a result for each id, in the order of the ids
+func newBulkResults(ids []int64) []BulkResult {
+	results := make([]BulkResult, len(ids))
+
+	for i, id := range ids {
+		results[i].Id = id
+	}
+
+	return results
+}
+
+// setAffected marks the results of the rows that a write did not affect, because they do not exist, as not found
+func setAffected(results []BulkResult, affected []int64) {
+	found := map[int64]bool{}
+
+	for _, id := range affected {
+		found[id] = true
+	}
+
+	for i := range results {
+		if !found[results[i].Id] {
+			results[i].Err = ErrNotFound
+		}
+	}
+}
+
+func batchEnd(start int, length int) int {
+	if start+bulkBatchSize > length {
+		return length
+	}
+
+	return start + bulkBatchSize
+}
+
+func quoteAll(names []string) []string {
+	quoted := make([]string, len(names))
+
+	for i, name := range names {
+		quoted[i] = quotes(name)
+	}
+
+	return quoted
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+	"strings"
+)
+
+func (s *StoreTestSuite) TestCreateUsers() {
+	users := []*data.User{
+		{FirstName: "foo", LastName: "bar"},
+		{FirstName: "", LastName: "bar"},
+		{FirstName: "baz", LastName: "qux"},
+	}
+
+	results := s.Store.CreateUsers(users)
+	s.Require().Len(results, 3)
+
+	s.Assert().NoError(results[0].Err)
+	s.Assert().Error(results[1].Err)
+	s.Assert().NoError(results[2].Err)
+	s.Assert().Equal(users[0].Id, results[0].Id)
+	s.Assert().Equal(users[2].Id, results[2].Id)
+
+	retrieved, _ := s.Store.GetUserById(users[2].Id)
+	s.Assert().EqualValues(users[2], retrieved)
+}
+
+func (s *StoreTestSuite) TestCreateUsersWithConflict() {
+	users := []*data.User{
+		{FirstName: "foo", LastName: "bar", Email: stringPtr("foo@example.com")},
+		{FirstName: "baz", LastName: "qux", Email: stringPtr("FOO@example.com")},
+		{FirstName: "abc", LastName: "def"},
+	}
+
+	results := s.Store.CreateUsers(users)
+	s.Require().Len(results, 3)
+
+	// the conflicting user fails without failing the others of its batch
+	s.Assert().NoError(results[0].Err)
+	s.Assert().True(errors.Is(results[1].Err, data.ErrConflict))
+	s.Assert().Zero(results[1].Id)
+	s.Assert().NoError(results[2].Err)
+	s.Assert().NotZero(results[0].Id)
+	s.Assert().NotZero(results[2].Id)
+
+	for _, i := range []int{0, 2} {
+		retrieved, _ := s.Store.GetUserById(results[i].Id)
+		s.Assert().EqualValues(users[i], retrieved)
+	}
+}
+
+func (s *StoreTestSuite) TestCreateGroups() {
+	groups := []*data.Group{{Name: "foo"}, {Name: strings.Repeat("a", 101)}}
+
+	results := s.Store.CreateGroups(groups)
+	s.Assert().NoError(results[0].Err)
+	s.Assert().Error(results[1].Err)
+
+	retrieved, _ := s.Store.GetGroupById(groups[0].Id)
+	s.Assert().EqualValues(groups[0], retrieved)
+}
+
+func (s *StoreTestSuite) TestUpdateUsers() {
+	results := s.Store.UpdateUsers([]*data.User{
+		{Id: 100, FirstName: "abc", LastName: "ignored"},
+		{Id: 1000, FirstName: "abc"},
+		{Id: 101, FirstName: "def"},
+	}, "FirstName")
+
+	s.Assert().NoError(results[0].Err)
+	s.Assert().Equal(data.ErrResourceDNE, results[1].Err.Error())
+	s.Assert().NoError(results[2].Err)
+
+	u, _ := s.Store.GetUserById(100)
+	s.Assert().EqualValues(&data.User{Id: 100, FirstName: "abc", LastName: "B", Version: 2}, untimed(u))
+
+	u, _ = s.Store.GetUserById(101)
+	s.Assert().EqualValues(&data.User{Id: 101, FirstName: "def", LastName: "D", Version: 2}, untimed(u))
+}
+
+func (s *StoreTestSuite) TestUpdateUsersWithConflict() {
+	results := s.Store.UpdateUsers([]*data.User{
+		{Id: 100, Email: stringPtr("foo@example.com")},
+		{Id: 101, Email: stringPtr("foo@example.com")},
+		{Id: 102, Email: stringPtr("bar@example.com")},
+	}, "Email")
+
+	s.Assert().NoError(results[0].Err)
+	s.Assert().True(errors.Is(results[1].Err, data.ErrConflict))
+	s.Assert().Equal(int64(101), results[1].Id)
+	s.Assert().NoError(results[2].Err)
+
+	u, _ := s.Store.GetUserById(101)
+	s.Assert().Nil(u.Email)
+
+	u, _ = s.Store.GetUserById(102)
+	s.Assert().Equal("bar@example.com", *u.Email)
+}
+
+func (s *StoreTestSuite) TestUpdateGroups() {
+	results := s.Store.UpdateGroups([]*data.Group{{Id: 100, Name: "abc"}, {Id: 101, Name: ""}}, "Name")
+
+	s.Assert().NoError(results[0].Err)
+	s.Assert().Error(results[1].Err)
+
+	g, _ := s.Store.GetGroupById(100)
+	s.Assert().Equal("abc", g.Name)
+
+	g, _ = s.Store.GetGroupById(101)
+	s.Assert().Equal("B", g.Name)
+}
+
+func (s *StoreTestSuite) TestDeleteUsers() {
+	results := s.Store.DeleteUsers([]int64{100, 1000, 101})
+
+	s.Assert().NoError(results[0].Err)
+	s.Assert().Equal(data.ErrResourceDNE, results[1].Err.Error())
+	s.Assert().NoError(results[2].Err)
+
+	u, _ := s.Store.GetUserById(100)
+	s.Assert().Nil(u)
+
+	u, _ = s.Store.GetUserById(101)
+	s.Assert().Nil(u)
+}
+
+func (s *StoreTestSuite) TestDeleteGroups() {
+	results := s.Store.DeleteGroups([]int64{100, 1000})
+
+	s.Assert().NoError(results[0].Err)
+	s.Assert().Equal(data.ErrResourceDNE, results[1].Err.Error())
+}
+
+func (s *StoreTestSuite) TestLinkGroupToUsers() {
+	results := s.Store.LinkGroupToUsers(201, []int64{100, 201, 1000, 101, 100})
+	s.Require().Len(results, 5)
+
+	s.Assert().NoError(results[0].Err)
+	s.Assert().Equal(data.ErrGroupUserAlreadyLinked, results[1].Err.Error())
+	s.Assert().Equal(data.ErrGroupOrUserDNE, results[2].Err.Error())
+	s.Assert().NoError(results[3].Err)
+	s.Assert().Equal(data.ErrGroupUserAlreadyLinked, results[4].Err.Error())
+
+	users, _ := s.Store.GetUsersByGroupId(201)
+	s.Assert().Len(users, 4)
+
+	results = s.Store.LinkGroupToUsers(1000, []int64{100})
+	s.Assert().Equal(data.ErrGroupOrUserDNE, results[0].Err.Error())
+
+	results = s.Store.LinkGroupToUsers(200, []int64{100, 101}, data.RoleAdmin)
+	s.Assert().NoError(results[0].Err)
+	s.Assert().NoError(results[1].Err)
+
+	m, _ := s.Store.GetMembership(200, 101)
+	s.Assert().Equal(data.RoleAdmin, m.Role)
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNewErrorClassifiesDatabaseErrors(t *testing.T) {
+	cases := []struct {
+		err  *pq.Error
+		msg  string
+		kind error
+	}{
+		{&pq.Error{Code: "23505", Constraint: "group_user_pkey"}, data.ErrGroupUserAlreadyLinked, data.ErrConflict},
+		{&pq.Error{Code: "23505", Constraint: "other"}, data.ErrResourceExists, data.ErrConflict},
+		{&pq.Error{Code: "23505", Constraint: "user_email_key"}, data.ErrResourceExists + ": email is taken", data.ErrConflict},
+		{&pq.Error{Code: "23503", Constraint: "group_user_user_id_fkey"}, data.ErrGroupOrUserDNE, data.ErrForeignKey},
+		{&pq.Error{Code: "23503"}, data.ErrReferenceDNE, data.ErrForeignKey},
+		{&pq.Error{Code: "23503", Constraint: "group_parent_id_fkey", Message: "insert or update on table \"group\" violates foreign key constraint \"group_parent_id_fkey\""}, data.ErrParentGroupDNE, data.ErrForeignKey},
+		{&pq.Error{Code: "23503", Constraint: "group_parent_id_fkey", Message: "update or delete on table \"group\" violates foreign key constraint \"group_parent_id_fkey\" on table \"group\""}, data.ErrGroupHasChildren, data.ErrConflict},
+		{&pq.Error{Code: "57014"}, data.ErrCanceled, data.ErrInterrupted},
+		{&pq.Error{Code: "08006"}, data.ErrConnectionFailed, data.ErrConnection},
+		{&pq.Error{Code: "42P01"}, data.ErrUnknown, nil},
+	}
+
+	for _, c := range cases {
+		err := data.NewError(c.err)
+
+		assert.Equal(t, c.msg, err.Error(), c.err.Code)
+		assert.Equal(t, c.err, errors.Unwrap(err), c.err.Code)
+
+		for _, kind := range []error{data.ErrNotFound, data.ErrConflict, data.ErrForeignKey, data.ErrConnection, data.ErrInterrupted} {
+			assert.Equal(t, kind == c.kind, errors.Is(err, kind), c.err.Code)
+		}
+	}
+
+	assert.True(t, errors.Is(data.NewError(context.Canceled), context.Canceled))
+	assert.True(t, errors.Is(data.NewError(context.DeadlineExceeded), data.ErrInterrupted))
+	assert.True(t, errors.Is(data.NewError(driver.ErrBadConn), data.ErrConnection))
+	assert.Equal(t, data.ErrUnknown, data.NewError(errors.New("boom")).Error())
+}
+
+func TestMissingIdsError(t *testing.T) {
+	err := data.NewError(&data.MissingIdsError{Ids: []int64{3, 1}})
+
+	assert.Equal(t, data.ErrResourceDNE+": 3, 1", err.Error())
+	assert.True(t, errors.Is(err, data.ErrNotFound))
+	assert.False(t, errors.Is(err, data.ErrConflict))
+}
+
+func (s *StoreTestSuite) TestErrorKinds() {
+	err := s.Store.UpdateUser(1000, &data.User{FirstName: "abc"}, "FirstName")
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+
+	err = s.Store.LinkGroupToUser(201, 201)
+	s.Assert().True(errors.Is(err, data.ErrConflict))
+
+	var pqErr *pq.Error
+	s.Assert().True(errors.As(err, &pqErr))
+	s.Assert().Equal("group_user_pkey", pqErr.Constraint)
+
+	err = s.Store.LinkGroupToUser(1000, 100)
+	s.Assert().True(errors.Is(err, data.ErrForeignKey))
+
+	err = s.Store.UpdateUserIfVersion(101, 5, &data.User{FirstName: "abc"}, "FirstName")
+	s.Assert().True(errors.Is(err, data.ErrConflict))
+
+	err = s.Store.DeleteUser(202, data.DeleteRestrict)
+	s.Assert().True(errors.Is(err, data.ErrConflict))
+	s.Assert().False(errors.Is(err, data.ErrNotFound))
+}
+
+func (s *StoreTestSuite) TestAlreadyExistsError() {
+	email := "bo@peep.com"
+	_, err := s.Store.CreateUser(&data.User{FirstName: "Bo", LastName: "Peep", Email: &email})
+	s.Require().NoError(err)
+
+	email = "BO@Peep.com"
+	_, err = s.Store.CreateUser(&data.User{FirstName: "Bo", LastName: "Peep", Email: &email})
+
+	var existsErr *data.AlreadyExistsError
+	s.Require().True(errors.As(err, &existsErr))
+	s.Assert().Equal("email", existsErr.Field)
+	s.Assert().True(errors.Is(err, data.ErrConflict))
+}
+
+func (s *StoreTestSuite) TestValidationError() {
+	_, err := s.Store.CreateUser(&data.User{LastName: strings.Repeat("a", 101)})
+
+	var validationErr *data.ValidationError
+	s.Require().True(errors.As(err, &validationErr))
+	s.Assert().Equal([]data.Violation{
+		{Field: "firstName", Rule: "required", Message: "firstName is required"},
+		{Field: "lastName", Rule: "lte=100", Message: "lastName must be at most 100 characters"},
+	}, validationErr.Violations)
+	s.Assert().Equal(data.ErrInvalidResource+": firstName is required, lastName must be at most 100 characters", err.Error())
+
+	j, _ := json.Marshal(validationErr)
+	s.Assert().JSONEq(`{"violations":[
+		{"field":"firstName","rule":"required","message":"firstName is required"},
+		{"field":"lastName","rule":"lte=100","message":"lastName must be at most 100 characters"}
+	]}`, string(j))
+
+	err = s.Store.UpdateGroup(100, &data.Group{Name: ""}, "Name")
+	s.Require().True(errors.As(err, &validationErr))
+	s.Assert().Equal([]data.Violation{{Field: "name", Rule: "required", Message: "name is required"}}, validationErr.Violations)
+}
@@ -0,0 +1,257 @@
+package tests
+
+import (
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+)
+
+func (s *StoreTestSuite) TestListUsers() {
+	page, err := s.Store.ListUsers(data.ListOptions{Limit: 3})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.User{
+		{Id: 100, FirstName: "A", LastName: "B", Version: 1},
+		{Id: 101, FirstName: "C", LastName: "D", Version: 1},
+		{Id: 102, FirstName: "E", LastName: "F", Version: 1},
+	}, untimed(page.Users))
+	s.Assert().NotEmpty(page.NextCursor)
+
+	page, err = s.Store.ListUsers(data.ListOptions{Limit: 3, Cursor: page.NextCursor})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.User{
+		{Id: 200, FirstName: "G", LastName: "H", Version: 1},
+		{Id: 201, FirstName: "I", LastName: "J", Version: 1},
+		{Id: 202, FirstName: "K", LastName: "L", Version: 1},
+	}, untimed(page.Users))
+
+	page, err = s.Store.ListUsers(data.ListOptions{Limit: 3, Cursor: page.NextCursor})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.User{{Id: 203, FirstName: "M", LastName: "N", Version: 1}}, untimed(page.Users))
+	s.Assert().Empty(page.NextCursor)
+
+	page, err = s.Store.ListUsers(data.ListOptions{})
+	s.Require().NoError(err)
+	s.Assert().Len(page.Users, 7)
+	s.Assert().Empty(page.NextCursor)
+
+	_, err = s.Store.ListUsers(data.ListOptions{Cursor: "not a cursor"})
+	s.Assert().Equal(data.ErrInvalidCursor, err.Error())
+	s.Assert().True(errors.Is(err, data.ErrInvalidOptions))
+}
+
+func (s *StoreTestSuite) TestListGroups() {
+	page, err := s.Store.ListGroups(data.ListOptions{Limit: 4})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.Group{
+		{Id: 100, Name: "A", Version: 1},
+		{Id: 101, Name: "B", Version: 1},
+		{Id: 102, Name: "C", Version: 1},
+		{Id: 200, Name: "D", Version: 1},
+	}, untimed(page.Groups))
+
+	page, err = s.Store.ListGroups(data.ListOptions{Limit: 4, Cursor: page.NextCursor})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.Group{
+		{Id: 201, Name: "E", Version: 1},
+		{Id: 202, Name: "F", Version: 1},
+		{Id: 203, Name: "G", Version: 1},
+	}, untimed(page.Groups))
+	s.Assert().Empty(page.NextCursor)
+}
+
+func (s *StoreTestSuite) TestListUsersFiltered() {
+	_, _ = s.Store.CreateUser(&data.User{FirstName: "Bo", LastName: "Peep"})
+	_, _ = s.Store.CreateUser(&data.User{FirstName: "Jo", LastName: "PEEPS"})
+	_, _ = s.Store.CreateUser(&data.User{FirstName: "Al", LastName: "Sleepy"})
+
+	page, err := s.Store.ListUsers(data.ListOptions{
+		Filters: []data.Filter{{Field: "lastName", Op: data.OpContains, Value: "pee"}},
+		Sort:    []data.Sort{{Field: "firstName", Desc: true}},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"Jo", "Bo", "Al"}, firstNames(page.Users))
+
+	page, err = s.Store.ListUsers(data.ListOptions{
+		Filters: []data.Filter{{Field: "last_name", Op: data.OpPrefix, Value: "pee"}},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"Bo", "Jo"}, firstNames(page.Users))
+
+	page, err = s.Store.ListUsers(data.ListOptions{
+		Filters: []data.Filter{{Field: "id", Op: data.OpGte, Value: "201"}, {Field: "id", Op: data.OpLt, Value: int64(203)}},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"I", "K"}, firstNames(page.Users))
+
+	_, err = s.Store.ListUsers(data.ListOptions{
+		Filters: []data.Filter{{Field: "password", Op: data.OpEq, Value: "x"}},
+	})
+	s.Assert().Equal(data.ErrInvalidField, err.Error())
+	s.Assert().True(errors.Is(err, data.ErrInvalidOptions))
+
+	_, err = s.Store.ListUsers(data.ListOptions{
+		Filters: []data.Filter{{Field: "id", Op: data.OpContains, Value: "1"}},
+	})
+	s.Assert().Equal(data.ErrInvalidFilter, err.Error())
+	s.Assert().True(errors.Is(err, data.ErrInvalidOptions))
+}
+
+func (s *StoreTestSuite) TestListUsersSortedPages() {
+	_, _ = s.Store.CreateUser(&data.User{FirstName: "C", LastName: "Z"})
+
+	opts := data.ListOptions{
+		Limit: 2,
+		Sort:  []data.Sort{{Field: "firstName", Desc: true}},
+	}
+
+	var names []string
+
+	for {
+		page, err := s.Store.ListUsers(opts)
+		s.Require().NoError(err)
+		names = append(names, firstNames(page.Users)...)
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		opts.Cursor = page.NextCursor
+	}
+
+	s.Assert().Equal([]string{"M", "K", "I", "G", "E", "C", "C", "A"}, names)
+
+	// a cursor cannot be used with a different sort
+	opts.Sort = []data.Sort{{Field: "lastName"}}
+	_, err := s.Store.ListUsers(opts)
+	s.Assert().Equal(data.ErrInvalidCursor, err.Error())
+	s.Assert().True(errors.Is(err, data.ErrInvalidOptions))
+}
+
+func firstNames(users []data.User) []string {
+	names := []string{}
+
+	for _, u := range users {
+		names = append(names, u.FirstName)
+	}
+
+	return names
+}
+
+func (s *StoreTestSuite) TestListUsersByGroupId() {
+	page, err := s.Store.ListUsersByGroupId(201, data.ListOptions{Limit: 1, Count: true})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.User{{Id: 201, FirstName: "I", LastName: "J", Version: 1}}, untimed(page.Users))
+	s.Assert().Equal(int64(2), *page.Total)
+
+	page, err = s.Store.ListUsersByGroupId(201, data.ListOptions{Limit: 1, Cursor: page.NextCursor})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.User{{Id: 202, FirstName: "K", LastName: "L", Version: 1}}, untimed(page.Users))
+	s.Assert().Empty(page.NextCursor)
+	s.Assert().Nil(page.Total)
+
+	page, err = s.Store.ListUsersByGroupId(201, data.ListOptions{
+		Filters: []data.Filter{{Field: "firstName", Op: data.OpEq, Value: "K"}},
+		Count:   true,
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.User{{Id: 202, FirstName: "K", LastName: "L", Version: 1}}, untimed(page.Users))
+	s.Assert().Equal(int64(1), *page.Total)
+
+	page, err = s.Store.ListUsersByGroupId(100, data.ListOptions{Count: true})
+	s.Require().NoError(err)
+	s.Assert().Empty(page.Users)
+	s.Assert().Equal(int64(0), *page.Total)
+}
+
+func (s *StoreTestSuite) TestListGroupsByUserId() {
+	page, err := s.Store.ListGroupsByUserId(202, data.ListOptions{
+		Sort:  []data.Sort{{Field: "name", Desc: true}},
+		Count: true,
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.Group{{Id: 202, Name: "F", Version: 1}, {Id: 201, Name: "E", Version: 1}}, untimed(page.Groups))
+	s.Assert().Equal(int64(2), *page.Total)
+}
+
+func (s *StoreTestSuite) TestListMemberships() {
+	page, err := s.Store.ListMemberships(data.ListOptions{Limit: 3, Count: true})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.Membership{
+		{GroupId: 201, UserId: 201, Role: data.RoleMember},
+		{GroupId: 201, UserId: 202, Role: data.RoleMember},
+		{GroupId: 202, UserId: 202, Role: data.RoleMember},
+	}, untimed(page.Memberships))
+	s.Assert().Equal(int64(4), *page.Total)
+
+	page, err = s.Store.ListMemberships(data.ListOptions{Limit: 3, Cursor: page.NextCursor})
+	s.Require().NoError(err)
+	s.Assert().Equal([]data.Membership{{GroupId: 203, UserId: 203, Role: data.RoleMember}}, untimed(page.Memberships))
+	s.Assert().Empty(page.NextCursor)
+}
+
+func (s *StoreTestSuite) TestListUsersByGroupIds() {
+	lists, missing, err := s.Store.ListUsersByGroupIds([]int64{201, 203, 1000}, data.ListOptions{Limit: 1})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{1000}, missing)
+	s.Assert().Len(lists, 3)
+	s.Assert().Equal([]int64{201}, userIds(lists[201].Users))
+	s.Assert().Equal([]int64{203}, userIds(lists[203].Users))
+	s.Assert().Empty(lists[1000].Users)
+	s.Assert().Empty(lists[203].NextCursor)
+
+	page, err := s.Store.ListUsersByGroupId(201, data.ListOptions{Limit: 1})
+	s.Require().NoError(err)
+	s.Assert().Equal(page.NextCursor, lists[201].NextCursor)
+
+	lists, _, err = s.Store.ListUsersByGroupIds([]int64{201}, data.ListOptions{Cursor: page.NextCursor})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{202}, userIds(lists[201].Users))
+
+	lists, _, err = s.Store.ListUsersByGroupIds([]int64{201}, data.ListOptions{
+		Filters: []data.Filter{{Field: "firstName", Op: data.OpEq, Value: "I"}},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"I"}, firstNames(lists[201].Users))
+
+	_, _, err = s.Store.ListGroupsByUserIds([]int64{202}, data.ListOptions{Cursor: "not a cursor"})
+	s.Assert().True(errors.Is(err, data.ErrInvalidOptions))
+}
+
+func (s *StoreTestSuite) TestListSince() {
+	created, err := s.Store.CreateUser(&data.User{FirstName: "foo", LastName: "bar"})
+	s.Require().NoError(err)
+	since := created.CreatedAt
+
+	page, err := s.Store.ListUsers(data.ListOptions{Since: since})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"foo"}, firstNames(page.Users))
+
+	_ = s.Store.UpdateUser(100, &data.User{FirstName: "abc"}, "FirstName")
+	page, _ = s.Store.ListUsers(data.ListOptions{Since: since})
+	s.Assert().Equal([]string{"foo", "abc"}, firstNames(page.Users))
+
+	groups, _ := s.Store.ListGroups(data.ListOptions{Since: since})
+	s.Assert().Empty(groups.Groups)
+
+	_ = s.Store.LinkGroupToUser(200, 200)
+
+	users, _ := s.Store.ListUsersByGroupId(200, data.ListOptions{Since: since})
+	s.Assert().Equal([]string{"G"}, firstNames(users.Users))
+
+	users, _ = s.Store.ListUsersByGroupId(201, data.ListOptions{Since: since})
+	s.Assert().Empty(users.Users)
+
+	memberships, _ := s.Store.ListMemberships(data.ListOptions{Since: since})
+	s.Assert().Equal([]data.Membership{{GroupId: 200, UserId: 200, Role: data.RoleMember}}, untimed(memberships.Memberships))
+
+	users, _ = s.Store.ListUsersInGroupTree(200, data.ListOptions{Since: since})
+	s.Assert().Equal([]string{"G"}, firstNames(users.Users))
+
+	users, _ = s.Store.ListUsersInGroupTree(201, data.ListOptions{Since: since})
+	s.Assert().Empty(users.Users)
+
+	groups, _ = s.Store.ListEffectiveGroupsByUserId(200, data.ListOptions{Since: since})
+	s.Assert().Equal([]int64{200}, groupIds(groups.Groups))
+
+	groups, _ = s.Store.ListEffectiveGroupsByUserId(201, data.ListOptions{Since: since})
+	s.Assert().Empty(groups.Groups)
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+	"sync"
+)
+
+func (s *StoreTestSuite) TestLoader() {
+	loader := s.Store.NewLoader()
+	ctx := context.Background()
+
+	lookupIds := []int64{201, 202, 203, 1000}
+	users := make([][]int64, len(lookupIds))
+	var wg sync.WaitGroup
+
+	// concurrent lookups share a batch
+	for i, id := range lookupIds {
+		wg.Add(1)
+
+		go func(i int, id int64) {
+			defer wg.Done()
+
+			u, err := loader.UsersByGroupId(ctx, id)
+			s.Assert().NoError(err)
+			users[i] = userIds(u)
+		}(i, id)
+	}
+
+	wg.Wait()
+
+	s.Assert().Equal([][]int64{{201, 202}, {202}, {203}, {}}, users)
+
+	groups, err := loader.GroupsByUserId(ctx, 202)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{201, 202}, groupIds(groups))
+
+	// soft-deleted users are left out
+	s.Require().NoError(s.Store.DeleteUser(202))
+
+	u, err := s.Store.NewLoader().UsersByGroupId(ctx, 201)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{201}, userIds(u))
+}
+
+func (s *StoreTestSuite) TestLoaderCanceledLookup() {
+	loader := s.Store.NewLoader()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the canceled lookup starts the batch that the others join
+	_, err := loader.UsersByGroupId(canceled, 201)
+	s.Assert().True(errors.Is(err, context.Canceled))
+
+	u, err := loader.UsersByGroupId(context.Background(), 202)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{202}, userIds(u))
+
+	u, err = loader.UsersByGroupId(context.Background(), 201)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{201, 202}, userIds(u))
+}
+
+func (s *StoreTestSuite) TestLoaderPages() {
+	loader := s.Store.NewLoader()
+	ctx := context.Background()
+
+	lookupIds := []int64{201, 202, 1000}
+	pages := make([]*data.UserList, len(lookupIds))
+	var wg sync.WaitGroup
+
+	// lookups with the same options share a batch
+	for i, id := range lookupIds {
+		wg.Add(1)
+
+		go func(i int, id int64) {
+			defer wg.Done()
+
+			page, err := loader.ListUsersByGroupId(ctx, id, data.ListOptions{Limit: 1})
+			s.Assert().NoError(err)
+			pages[i] = page
+		}(i, id)
+	}
+
+	wg.Wait()
+
+	s.Assert().Equal([]int64{201}, userIds(pages[0].Users))
+	s.Assert().NotEmpty(pages[0].NextCursor)
+	s.Assert().Equal([]int64{202}, userIds(pages[1].Users))
+	s.Assert().Empty(pages[1].NextCursor)
+	s.Assert().Empty(pages[2].Users)
+
+	// the cursor continues the page of the group, as it does for ListUsersByGroupId
+	page, err := loader.ListUsersByGroupId(ctx, 201, data.ListOptions{Limit: 1, Cursor: pages[0].NextCursor})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{202}, userIds(page.Users))
+	s.Assert().Empty(page.NextCursor)
+
+	groups, err := loader.ListGroupsByUserId(ctx, 202, data.ListOptions{Sort: []data.Sort{{Field: "id", Desc: true}}})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{202, 201}, groupIds(groups.Groups))
+}
+
+func (s *StoreTestSuite) TestStrictLoaderMissingIds() {
+	loader := s.Store.Strict().NewLoader()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	var found, missing error
+
+	// the missing group fails its own lookup, but not the other lookup of its batch
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		_, found = loader.UsersByGroupId(ctx, 203)
+	}()
+
+	go func() {
+		defer wg.Done()
+		_, missing = loader.UsersByGroupId(ctx, 1000)
+	}()
+
+	wg.Wait()
+
+	s.Assert().NoError(found)
+	s.Assert().True(errors.Is(missing, data.ErrNotFound))
+
+	_, err := loader.ListGroupsByUserId(ctx, 1000, data.ListOptions{})
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+}
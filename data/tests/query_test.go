@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"github.com/brietsparks/xcrud/data"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	cases := map[string]data.Filter{
+		"lastName~=pee": {Field: "lastName", Op: data.OpContains, Value: "pee"},
+		"firstName^=Bo": {Field: "firstName", Op: data.OpPrefix, Value: "Bo"},
+		"id<=5":         {Field: "id", Op: data.OpLte, Value: "5"},
+		"id<5":          {Field: "id", Op: data.OpLt, Value: "5"},
+		"name!=a=b":     {Field: "name", Op: data.OpNe, Value: "a=b"},
+		"name=":         {Field: "name", Op: data.OpEq, Value: ""},
+	}
+
+	for expr, expected := range cases {
+		filter, err := data.ParseFilter(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, filter, expr)
+	}
+
+	_, err := data.ParseFilter("lastName")
+	assert.Error(t, err)
+
+	_, err = data.ParseFilter("=pee")
+	assert.Error(t, err)
+}
+
+func TestParseSort(t *testing.T) {
+	assert.Equal(t, data.Sort{Field: "firstName", Desc: true}, data.ParseSort("-firstName"))
+	assert.Equal(t, data.Sort{Field: "firstName"}, data.ParseSort("+firstName"))
+	assert.Equal(t, data.Sort{Field: "firstName"}, data.ParseSort("firstName"))
+}
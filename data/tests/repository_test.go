@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// account is a resource type that is not known to the store, stored in the user table
+type account struct {
+	Id        int64      `db:"id" json:"id"`
+	FirstName string     `db:"first_name" json:"firstName" validate:"required"`
+	LastName  string     `db:"last_name" json:"lastName"`
+	Version   int64      `db:"version" json:"version"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt,omitempty"`
+}
+
+func (account) TableName() string {
+	return "user"
+}
+
+func TestNewRepositoryRequiresManagedColumns(t *testing.T) {
+	type note struct {
+		Id   int64  `db:"id"`
+		Text string `db:"text"`
+	}
+
+	assert.Panics(t, func() { data.NewRepository[note](nil) })
+	assert.NotPanics(t, func() { data.NewRepository[account](nil) })
+}
+
+func (s *StoreTestSuite) TestRepository() {
+	accounts := data.NewRepository[account](s.Store)
+
+	a, err := accounts.Create(&account{FirstName: "Bo", LastName: "Peep"})
+	s.Require().NoError(err)
+	s.Assert().NotZero(a.Id)
+	s.Assert().Equal(int64(1), a.Version)
+
+	u, _ := s.Store.GetUserById(a.Id)
+	s.Assert().Equal("Peep", u.LastName)
+
+	// only fields with a validate tag can be updated
+	err = accounts.Update(a.Id, &account{FirstName: "Jo", LastName: "Jackson"}, "FirstName", "LastName")
+	s.Require().NoError(err)
+
+	a, _ = accounts.GetById(a.Id)
+	s.Assert().Equal("Jo", a.FirstName)
+	s.Assert().Equal("Peep", a.LastName)
+	s.Assert().Equal(int64(2), a.Version)
+
+	err = accounts.UpdateIfVersion(a.Id, 1, &account{FirstName: "Al"}, "FirstName")
+	var versionErr *data.VersionConflictError
+	s.Require().True(errors.As(err, &versionErr))
+
+	_, err = accounts.Create(&account{LastName: "Peep"})
+	var validationErr *data.ValidationError
+	s.Require().True(errors.As(err, &validationErr))
+
+	page, err := accounts.List(data.ListOptions{Limit: 2, Count: true})
+	s.Require().NoError(err)
+	s.Assert().Len(page.Items, 2)
+	s.Assert().Equal(int64(8), *page.Total)
+	s.Assert().NotEmpty(page.NextCursor)
+
+	s.Require().NoError(accounts.Delete(a.Id))
+
+	a, err = accounts.GetById(a.Id)
+	s.Assert().Nil(a)
+	s.Assert().NoError(err)
+
+	groups := data.NewRepository[data.Group](s.Store.Strict())
+
+	_, err = groups.GetById(1000)
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"flag"
@@ -9,7 +10,9 @@ import (
 	"github.com/stretchr/testify/suite"
 	"gopkg.in/testfixtures.v2"
 	"log"
+	"reflect"
 	"testing"
+	"time"
 )
 
 type StoreTestSuite struct {
@@ -108,13 +111,85 @@ func (s *StoreTestSuite) TestGetUserById() {
 		Id:        100,
 		FirstName: "A",
 		LastName:  "B",
+		Version:   1,
 	}
-	s.Assert().EqualValues(expected, u)
+	s.Assert().EqualValues(expected, untimed(u))
 
 	u, _ = s.Store.GetUserById(1000)
 	s.Assert().Nil(u)
 }
 
+func (s *StoreTestSuite) TestStrictGetById() {
+	strict := s.Store.Strict()
+
+	u, err := strict.GetUserById(100)
+	s.Require().NoError(err)
+	s.Assert().Equal("A", u.FirstName)
+
+	u, err = strict.GetUserById(1000)
+	s.Assert().Nil(u)
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+
+	g, err := strict.GetGroupById(1000)
+	s.Assert().Nil(g)
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+
+	err = strict.WithTx(context.Background(), func(tx *data.Store) error {
+		_, err := tx.GetUserById(1000)
+		return err
+	})
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+
+	u, err = s.Store.GetUserById(1000)
+	s.Assert().Nil(u)
+	s.Assert().NoError(err)
+}
+
+func (s *StoreTestSuite) TestUserProfile() {
+	email, username := "Bo@Peep.com", "bo.peep"
+	created, err := s.Store.CreateUser(&data.User{FirstName: "Bo", LastName: "Peep", Email: &email, Username: &username})
+	s.Require().NoError(err)
+
+	u, err := s.Store.GetUserByEmail("bo@peep.com")
+	s.Require().NoError(err)
+	s.Assert().Equal(created.Id, u.Id)
+	s.Assert().Equal("Bo@Peep.com", *u.Email)
+
+	u, err = s.Store.GetUserByUsername("BO.PEEP")
+	s.Require().NoError(err)
+	s.Assert().Equal(created.Id, u.Id)
+
+	u, err = s.Store.GetUserByEmail("nobody@peep.com")
+	s.Assert().Nil(u)
+	s.Assert().NoError(err)
+
+	_, err = s.Store.Strict().GetUserByUsername("nobody")
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+
+	username = "Bo.Peep"
+	err = s.Store.UpdateUser(100, &data.User{Username: &username}, "Username")
+
+	var existsErr *data.AlreadyExistsError
+	s.Require().True(errors.As(err, &existsErr))
+	s.Assert().Equal("username", existsErr.Field)
+
+	email, username = "not an email", "bo peep"
+	_, err = s.Store.CreateUser(&data.User{FirstName: "Bo", LastName: "Peep", Email: &email, Username: &username})
+
+	var validationErr *data.ValidationError
+	s.Require().True(errors.As(err, &validationErr))
+	s.Assert().Equal([]data.Violation{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "username", Rule: "username", Message: "username may only contain letters, digits, '.', '_' and '-'"},
+	}, validationErr.Violations)
+
+	err = s.Store.UpdateUser(created.Id, &data.User{}, "Email")
+	s.Require().NoError(err)
+
+	u, _ = s.Store.GetUserById(created.Id)
+	s.Assert().Nil(u.Email)
+}
+
 func (s *StoreTestSuite) TestUpdateUser() {
 	_ = s.Store.UpdateUser(101, &data.User{FirstName: "abc",})
 
@@ -123,8 +198,9 @@ func (s *StoreTestSuite) TestUpdateUser() {
 		Id:        101,
 		FirstName: "C",
 		LastName:  "D",
+		Version:   1,
 	}
-	s.Assert().EqualValues(expected, u)
+	s.Assert().EqualValues(expected, untimed(u))
 
 	_ = s.Store.UpdateUser(101, &data.User{FirstName: "abc",}, "FirstName")
 	u, _ = s.Store.GetUserById(101)
@@ -132,14 +208,35 @@ func (s *StoreTestSuite) TestUpdateUser() {
 		Id:        101,
 		FirstName: "abc",
 		LastName:  "D",
+		Version:   2,
 	}
-	s.Assert().EqualValues(expected, u)
+	s.Assert().EqualValues(expected, untimed(u))
 
 	err := s.Store.UpdateUser(1000, &data.User{FirstName: "abc"}, "FirstName")
 	s.Assert().Equal(data.ErrResourceDNE, err.Error())
 	s.Assert().Nil(errors.Unwrap(err))
 }
 
+func (s *StoreTestSuite) TestUpdateUserIfVersion() {
+	err := s.Store.UpdateUserIfVersion(101, 1, &data.User{FirstName: "abc"}, "FirstName")
+	s.Require().NoError(err)
+
+	retrieved, _ := s.Store.GetUserById(101)
+	s.Assert().Equal("abc", retrieved.FirstName)
+	s.Assert().Equal(int64(2), retrieved.Version)
+
+	err = s.Store.UpdateUserIfVersion(101, 1, &data.User{FirstName: "def"}, "FirstName")
+	var conflict *data.VersionConflictError
+	s.Require().True(errors.As(err, &conflict))
+	s.Assert().Equal(&data.VersionConflictError{Expected: 1, Actual: 2}, conflict)
+
+	retrieved, _ = s.Store.GetUserById(101)
+	s.Assert().Equal("abc", retrieved.FirstName)
+
+	err = s.Store.UpdateUserIfVersion(1000, 1, &data.User{FirstName: "abc"}, "FirstName")
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+}
+
 func (s *StoreTestSuite) TestCreateUser() {
 	created, _ := s.Store.CreateUser(&data.User{
 		FirstName: "foo",
@@ -151,6 +248,24 @@ func (s *StoreTestSuite) TestCreateUser() {
 	s.Assert().EqualValues(created, retrieved)
 }
 
+func (s *StoreTestSuite) TestUserTimestamps() {
+	created, err := s.Store.CreateUser(&data.User{FirstName: "foo", LastName: "bar"})
+	s.Require().NoError(err)
+	s.Assert().False(created.CreatedAt.IsZero())
+	s.Assert().True(created.UpdatedAt.Equal(created.CreatedAt))
+
+	err = s.Store.UpdateUser(created.Id, &data.User{FirstName: "abc"}, "FirstName")
+	s.Require().NoError(err)
+
+	u, _ := s.Store.GetUserById(created.Id)
+	s.Assert().True(u.CreatedAt.Equal(created.CreatedAt))
+	s.Assert().True(u.UpdatedAt.After(created.UpdatedAt))
+
+	users := []*data.User{{FirstName: "foo", LastName: "bar"}}
+	s.Store.CreateUsers(users)
+	s.Assert().False(users[0].CreatedAt.IsZero())
+}
+
 func (s *StoreTestSuite) TestDeleteUser() {
 	retrieved, _ := s.Store.GetUserById(102)
 	s.Assert().NotNil(retrieved)
@@ -164,14 +279,163 @@ func (s *StoreTestSuite) TestDeleteUser() {
 	s.Assert().Nil(errors.Unwrap(err))
 }
 
+func (s *StoreTestSuite) TestSoftDeleteUser() {
+	err := s.Store.DeleteUser(202)
+	s.Require().NoError(err)
+
+	u, _ := s.Store.GetUserById(202)
+	s.Assert().Nil(u)
+
+	users, _ := s.Store.GetUsersByGroupId(201)
+	s.Assert().Equal([]data.User{{Id: 201, FirstName: "I", LastName: "J", Version: 1}}, untimed(users))
+
+	page, _ := s.Store.ListUsers(data.ListOptions{})
+	s.Assert().Len(page.Users, 6)
+
+	memberships, _ := s.Store.ListMemberships(data.ListOptions{})
+	s.Assert().Equal([]data.Membership{{GroupId: 201, UserId: 201, Role: data.RoleMember}, {GroupId: 203, UserId: 203, Role: data.RoleMember}}, untimed(memberships.Memberships))
+
+	err = s.Store.UpdateUser(202, &data.User{FirstName: "abc"}, "FirstName")
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+
+	err = s.Store.DeleteUser(202)
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+
+	err = s.Store.LinkGroupToUser(200, 202)
+	s.Assert().Equal(data.ErrGroupOrUserDNE, err.Error())
+
+	err = s.Store.RestoreUser(202)
+	s.Require().NoError(err)
+
+	u, _ = s.Store.GetUserById(202)
+	s.Assert().EqualValues(&data.User{Id: 202, FirstName: "K", LastName: "L", Version: 3}, untimed(u))
+
+	groups, _ := s.Store.GetGroupsByUserId(202)
+	s.Assert().Len(groups, 2)
+
+	err = s.Store.RestoreUser(202)
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+}
+
+func (s *StoreTestSuite) TestPurgeUser() {
+	_ = s.Store.DeleteUser(100)
+
+	err := s.Store.PurgeUser(100)
+	s.Require().NoError(err)
+
+	err = s.Store.RestoreUser(100)
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+
+	err = s.Store.PurgeUser(101)
+	s.Require().NoError(err)
+
+	err = s.Store.PurgeUser(1000)
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+}
+
+func (s *StoreTestSuite) TestDeleteUserPolicy() {
+	var membershipsErr *data.MembershipsExistError
+
+	err := s.Store.DeleteUser(202, data.DeleteRestrict)
+	s.Require().True(errors.As(err, &membershipsErr))
+	s.Assert().Equal([]data.Membership{{GroupId: 201, UserId: 202, Role: data.RoleMember}, {GroupId: 202, UserId: 202, Role: data.RoleMember}}, untimed(membershipsErr.Memberships))
+
+	u, _ := s.Store.GetUserById(202)
+	s.Assert().NotNil(u)
+
+	err = s.Store.DeleteUser(202, data.DeleteCascade)
+	s.Require().NoError(err)
+
+	_ = s.Store.RestoreUser(202)
+	groups, _ := s.Store.GetGroupsByUserId(202)
+	s.Assert().Nil(groups)
+
+	err = s.Store.PurgeUser(203)
+	s.Require().True(errors.As(err, &membershipsErr))
+	s.Assert().Equal([]data.Membership{{GroupId: 203, UserId: 203, Role: data.RoleMember}}, untimed(membershipsErr.Memberships))
+
+	err = s.Store.PurgeUser(203, data.DeleteCascade)
+	s.Require().NoError(err)
+
+	err = s.Store.RestoreUser(203)
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+
+	err = s.Store.DeleteUser(1000, data.DeleteCascade)
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+}
+
+func (s *StoreTestSuite) TestDeleteUserRestrictWaitsForLink() {
+	user, err := s.Store.CreateUser(&data.User{FirstName: "foo", LastName: "bar"})
+	s.Require().NoError(err)
+
+	linked := make(chan struct{})
+	release := make(chan struct{})
+	linkErr := make(chan error)
+
+	go func() {
+		linkErr <- s.Store.WithTx(context.Background(), func(tx *data.Store) error {
+			if err := tx.LinkGroupToUser(201, user.Id); err != nil {
+				close(linked)
+				return err
+			}
+
+			close(linked)
+			<-release
+			return nil
+		})
+	}()
+
+	<-linked
+	deleteErr := make(chan error)
+
+	go func() {
+		deleteErr <- s.Store.DeleteUser(user.Id, data.DeleteRestrict)
+	}()
+
+	select {
+	case err := <-deleteErr:
+		close(release)
+		s.Failf("delete did not wait for the link", "error: %v", err)
+		return
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	s.Require().NoError(<-linkErr)
+
+	var membershipsErr *data.MembershipsExistError
+	s.Assert().True(errors.As(<-deleteErr, &membershipsErr))
+
+	u, _ := s.Store.GetUserById(user.Id)
+	s.Assert().NotNil(u)
+}
+
+func (s *StoreTestSuite) TestDeleteGroupPolicy() {
+	var membershipsErr *data.MembershipsExistError
+
+	err := s.Store.PurgeGroup(201)
+	s.Require().True(errors.As(err, &membershipsErr))
+	s.Assert().Len(membershipsErr.Memberships, 2)
+
+	err = s.Store.PurgeGroup(201, data.DeleteCascade)
+	s.Require().NoError(err)
+
+	users, _ := s.Store.GetUsersByGroupId(201)
+	s.Assert().Nil(users)
+
+	u, _ := s.Store.GetUserById(201)
+	s.Assert().NotNil(u)
+}
+
 func (s *StoreTestSuite) TestGetGroupById() {
 	u, _ := s.Store.GetGroupById(100)
 
 	expected := &data.Group{
-		Id:   100,
-		Name: "A",
+		Id:      100,
+		Name:    "A",
+		Version: 1,
 	}
-	s.Assert().EqualValues(expected, u)
+	s.Assert().EqualValues(expected, untimed(u))
 
 	u, _ = s.Store.GetGroupById(1000)
 	s.Assert().Nil(u)
@@ -182,24 +446,46 @@ func (s *StoreTestSuite) TestUpdateGroup() {
 
 	u, _ := s.Store.GetGroupById(101)
 	expected := &data.Group{
-		Id:   101,
-		Name: "B",
+		Id:      101,
+		Name:    "B",
+		Version: 1,
 	}
-	s.Assert().EqualValues(expected, u)
+	s.Assert().EqualValues(expected, untimed(u))
 
 	_ = s.Store.UpdateGroup(101, &data.Group{Name: "abc",}, "Name")
 	u, _ = s.Store.GetGroupById(101)
 	expected = &data.Group{
-		Id:   101,
-		Name: "abc",
+		Id:      101,
+		Name:    "abc",
+		Version: 2,
 	}
-	s.Assert().EqualValues(expected, u)
+	s.Assert().EqualValues(expected, untimed(u))
 
 	err := s.Store.UpdateGroup(1000, &data.Group{Name: "abc",}, "Name")
 	s.Assert().Equal(data.ErrResourceDNE, err.Error())
 	s.Assert().Nil(errors.Unwrap(err))
 }
 
+func (s *StoreTestSuite) TestUpdateGroupIfVersion() {
+	err := s.Store.UpdateGroupIfVersion(101, 1, &data.Group{Name: "abc"}, "Name")
+	s.Require().NoError(err)
+
+	retrieved, _ := s.Store.GetGroupById(101)
+	s.Assert().Equal("abc", retrieved.Name)
+	s.Assert().Equal(int64(2), retrieved.Version)
+
+	err = s.Store.UpdateGroupIfVersion(101, 1, &data.Group{Name: "def"}, "Name")
+	var conflict *data.VersionConflictError
+	s.Require().True(errors.As(err, &conflict))
+	s.Assert().Equal(&data.VersionConflictError{Expected: 1, Actual: 2}, conflict)
+
+	retrieved, _ = s.Store.GetGroupById(101)
+	s.Assert().Equal("abc", retrieved.Name)
+
+	err = s.Store.UpdateGroupIfVersion(1000, 1, &data.Group{Name: "abc"}, "Name")
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+}
+
 func (s *StoreTestSuite) TestCreateGroup() {
 	created, _ := s.Store.CreateGroup(&data.Group{
 		Name: "foo",
@@ -223,46 +509,245 @@ func (s *StoreTestSuite) TestDeleteGroup() {
 	s.Assert().Nil(errors.Unwrap(err))
 }
 
+func (s *StoreTestSuite) TestSoftDeleteGroup() {
+	err := s.Store.DeleteGroup(202)
+	s.Require().NoError(err)
+
+	g, _ := s.Store.GetGroupById(202)
+	s.Assert().Nil(g)
+
+	groups, _ := s.Store.GetGroupsByUserId(202)
+	s.Assert().Equal([]data.Group{{Id: 201, Name: "E", Version: 1}}, untimed(groups))
+
+	users, _ := s.Store.GetUsersByGroupId(202)
+	s.Assert().Nil(users)
+
+	err = s.Store.RestoreGroup(202)
+	s.Require().NoError(err)
+
+	g, _ = s.Store.GetGroupById(202)
+	s.Assert().EqualValues(&data.Group{Id: 202, Name: "F", Version: 3}, untimed(g))
+
+	err = s.Store.PurgeGroup(100)
+	s.Require().NoError(err)
+
+	err = s.Store.RestoreGroup(100)
+	s.Assert().Equal(data.ErrResourceDNE, err.Error())
+}
+
 func (s *StoreTestSuite) TestGetUsersByGroupId() {
 	users, _ := s.Store.GetUsersByGroupId(201)
 
 	expected := []data.User{
-		{Id: 201, FirstName: "I", LastName: "J"},
-		{Id: 202, FirstName: "K", LastName: "L"},
+		{Id: 201, FirstName: "I", LastName: "J", Version: 1},
+		{Id: 202, FirstName: "K", LastName: "L", Version: 1},
 	}
 
-	s.Assert().Equal(expected, users)
+	s.Assert().Equal(expected, untimed(users))
 }
 
 func (s *StoreTestSuite) TestGetGroupsByUserId() {
 	groups, _ := s.Store.GetGroupsByUserId(202)
 
 	expected := []data.Group{
-		{Id: 201, Name: "E"},
-		{Id: 202, Name: "F"},
+		{Id: 201, Name: "E", Version: 1},
+		{Id: 202, Name: "F", Version: 1},
 	}
 
-	s.Assert().Equal(expected, groups)
+	s.Assert().Equal(expected, untimed(groups))
+}
+
+func (s *StoreTestSuite) TestGetUsersByIds() {
+	s.Require().NoError(s.Store.DeleteUser(202))
+
+	users, missing, err := s.Store.GetUsersByIds([]int64{201, 1000, 100, 202})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{201, 100}, userIds(users))
+	s.Assert().Equal([]int64{1000, 202}, missing)
+
+	users, missing, err = s.Store.GetUsersByIds(nil)
+	s.Require().NoError(err)
+	s.Assert().Empty(users)
+	s.Assert().Empty(missing)
+
+	users, missing, err = s.Store.Strict().GetUsersByIds([]int64{100, 1000})
+	s.Assert().Equal([]int64{100}, userIds(users))
+	s.Assert().Equal([]int64{1000}, missing)
+
+	var missingErr *data.MissingIdsError
+	s.Require().True(errors.As(err, &missingErr))
+	s.Assert().Equal([]int64{1000}, missingErr.Ids)
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+}
+
+func (s *StoreTestSuite) TestGetGroupsByIds() {
+	groups, missing, err := s.Store.GetGroupsByIds([]int64{203, 201, 1000, 203})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{203, 201, 203}, groupIds(groups))
+	s.Assert().Equal([]int64{1000}, missing)
+	s.Assert().Equal(data.Group{Id: 201, Name: "E", Version: 1}, untimed(groups[1]))
+}
+
+func (s *StoreTestSuite) TestGetUsersByGroupIds() {
+	users, missing, err := s.Store.GetUsersByGroupIds([]int64{201, 203, 1000})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{1000}, missing)
+
+	s.Assert().Equal(map[int64][]data.User{
+		201: {
+			{Id: 201, FirstName: "I", LastName: "J", Version: 1},
+			{Id: 202, FirstName: "K", LastName: "L", Version: 1},
+		},
+		203: {{Id: 203, FirstName: "M", LastName: "N", Version: 1}},
+		1000: {},
+	}, untimed(users))
+
+	// a strict store fails for the groups that do not exist
+	users, missing, err = s.Store.Strict().GetUsersByGroupIds([]int64{203, 1000, 1001})
+	var missingErr *data.MissingIdsError
+	s.Require().True(errors.As(err, &missingErr))
+	s.Assert().Equal([]int64{1000, 1001}, missingErr.Ids)
+	s.Assert().Equal([]int64{1000, 1001}, missing)
+	s.Assert().Equal([]int64{203}, userIds(users[203]))
+}
+
+func (s *StoreTestSuite) TestGetGroupsByUserIds() {
+	s.Require().NoError(s.Store.DeleteGroup(201))
+
+	groups, missing, err := s.Store.GetGroupsByUserIds([]int64{202, 201, 1000})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{202}, groupIds(groups[202]))
+	s.Assert().Equal([]data.Group{}, groups[201])
+	s.Assert().Equal([]int64{1000}, missing)
+
+	// soft-deleted users are missing too
+	s.Require().NoError(s.Store.DeleteUser(201))
+
+	_, missing, err = s.Store.GetGroupsByUserIds([]int64{202, 201})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{201}, missing)
 }
 
 func (s *StoreTestSuite) TestLinkGroupToUser() {
 	_ = s.Store.LinkGroupToUser(200, 200)
 
 	users, _ := s.Store.GetUsersByGroupId(200)
-	expectedUsers := []data.User{{Id: 200, FirstName: "G", LastName: "H"}}
-	s.Assert().EqualValues(expectedUsers, users)
+	expectedUsers := []data.User{{Id: 200, FirstName: "G", LastName: "H", Version: 1}}
+	s.Assert().EqualValues(expectedUsers, untimed(users))
 
 	groups, _ := s.Store.GetGroupsByUserId(200)
-	expectedGroups := []data.Group{{Id: 200, Name: "D"}}
-	s.Assert().EqualValues(expectedGroups, groups)
+	expectedGroups := []data.Group{{Id: 200, Name: "D", Version: 1}}
+	s.Assert().EqualValues(expectedGroups, untimed(groups))
 
 	err := s.Store.LinkGroupToUser(200, 200)
 	s.Assert().Equal(data.ErrGroupUserAlreadyLinked, err.Error())
 	s.Assert().Equal(data.DbErrGroupUserAlreadyLinked, errors.Unwrap(err).Error())
 }
 
+func (s *StoreTestSuite) TestMembershipRoles() {
+	err := s.Store.LinkGroupToUser(200, 200, data.RoleOwner)
+	s.Require().NoError(err)
+
+	m, err := s.Store.GetMembership(200, 200)
+	s.Require().NoError(err)
+	s.Assert().Equal(data.RoleOwner, m.Role)
+	s.Assert().Nil(m.Attributes)
+
+	err = s.Store.LinkGroupToUser(200, 201, "guest")
+	var validationErr *data.ValidationError
+	s.Require().True(errors.As(err, &validationErr))
+	s.Assert().Equal("role", validationErr.Violations[0].Field)
+
+	err = s.Store.SetMemberRole(201, 202, data.RoleAdmin)
+	s.Require().NoError(err)
+
+	err = s.Store.SetMemberAttributes(201, 202, data.Attributes{"title": "lead"})
+	s.Require().NoError(err)
+
+	m, _ = s.Store.GetMembership(201, 202)
+	s.Assert().Equal(&data.Membership{
+		GroupId:    201,
+		UserId:     202,
+		Role:       data.RoleAdmin,
+		Attributes: data.Attributes{"title": "lead"},
+	}, untimed(m))
+
+	page, _ := s.Store.ListMemberships(data.ListOptions{
+		Filters: []data.Filter{{Field: "role", Op: data.OpEq, Value: "admin"}},
+	})
+	s.Assert().Len(page.Memberships, 1)
+
+	err = s.Store.SetMemberRole(200, 100, data.RoleAdmin)
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+
+	m, err = s.Store.GetMembership(200, 100)
+	s.Assert().Nil(m)
+	s.Assert().NoError(err)
+}
+
+func (s *StoreTestSuite) TestCreateMembership() {
+	m, err := s.Store.CreateMembership(&data.Membership{GroupId: 200, UserId: 200, Attributes: data.Attributes{"title": "lead"}})
+	s.Require().NoError(err)
+	s.Assert().Equal(&data.Membership{
+		GroupId:    200,
+		UserId:     200,
+		Role:       data.RoleMember,
+		Attributes: data.Attributes{"title": "lead"},
+	}, untimed(m))
+	s.Assert().False(m.LinkedAt.IsZero())
+
+	_, err = s.Store.CreateMembership(&data.Membership{GroupId: 200, UserId: 200, Role: data.RoleAdmin})
+	s.Assert().Equal(data.ErrGroupUserAlreadyLinked, err.Error())
+
+	_, err = s.Store.CreateMembership(&data.Membership{GroupId: 1000, UserId: 200})
+	s.Assert().True(errors.Is(err, data.ErrForeignKey))
+
+	// an invalid role leaves no membership behind
+	_, err = s.Store.CreateMembership(&data.Membership{GroupId: 200, UserId: 201, Role: "guest"})
+	var validationErr *data.ValidationError
+	s.Require().True(errors.As(err, &validationErr))
+
+	m, _ = s.Store.GetMembership(200, 201)
+	s.Assert().Nil(m)
+}
+
+func (s *StoreTestSuite) TestUpdateMembership() {
+	err := s.Store.UpdateMembership(201, 202, &data.Membership{Role: data.RoleAdmin, Attributes: data.Attributes{"title": "lead"}}, "Role", "Attributes")
+	s.Require().NoError(err)
+
+	m, _ := s.Store.GetMembership(201, 202)
+	s.Assert().Equal(&data.Membership{
+		GroupId:    201,
+		UserId:     202,
+		Role:       data.RoleAdmin,
+		Attributes: data.Attributes{"title": "lead"},
+	}, untimed(m))
+
+	// an invalid role leaves the attributes unchanged
+	err = s.Store.UpdateMembership(201, 202, &data.Membership{Role: "guest", Attributes: data.Attributes{"title": "head"}}, "Role", "Attributes")
+	var validationErr *data.ValidationError
+	s.Require().True(errors.As(err, &validationErr))
+
+	m, _ = s.Store.GetMembership(201, 202)
+	s.Assert().Equal(data.Attributes{"title": "lead"}, m.Attributes)
+
+	err = s.Store.UpdateMembership(201, 202, &data.Membership{Role: data.RoleOwner}, "Role")
+	s.Require().NoError(err)
+
+	m, _ = s.Store.GetMembership(201, 202)
+	s.Assert().Equal(data.RoleOwner, m.Role)
+	s.Assert().Equal(data.Attributes{"title": "lead"}, m.Attributes)
+
+	err = s.Store.UpdateMembership(200, 100, &data.Membership{Role: data.RoleAdmin}, "Role")
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+}
+
 func (s *StoreTestSuite) TestUnlinkGroupFromUser() {
-	_ = s.Store.UnlinkGroupFromUser(203, 203)
+	err := s.Store.UnlinkGroupFromUser(203, 203)
+	s.Require().NoError(err)
+
+	err = s.Store.UnlinkGroupFromUser(203, 203)
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
 
 	users, _ := s.Store.GetUsersByGroupId(203)
 	s.Assert().Nil(users)
@@ -271,6 +756,94 @@ func (s *StoreTestSuite) TestUnlinkGroupFromUser() {
 	s.Assert().Nil(groups)
 }
 
+func (s *StoreTestSuite) TestCanceledContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	u, err := s.Store.GetUserByIdContext(ctx, 100)
+	s.Assert().Nil(u)
+	s.Assert().Equal(data.ErrCanceled, err.Error())
+	s.Assert().True(errors.Is(err, context.Canceled))
+
+	_, err = s.Store.CreateGroupContext(ctx, &data.Group{Name: "foo"})
+	s.Assert().Equal(data.ErrCanceled, err.Error())
+}
+
+func (s *StoreTestSuite) TestDeadlineAbortsInFlightQuery() {
+	d := connect(s)
+	defer d.Close()
+
+	// hold a row lock so that the update blocks until its deadline passes
+	lock, err := d.Begin()
+	s.Require().NoError(err)
+	defer lock.Rollback()
+
+	_, err = lock.Exec(`select * from "user" where id = 100 for update`)
+	s.Require().NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = s.Store.UpdateUserContext(ctx, 100, &data.User{FirstName: "abc"}, "FirstName")
+
+	s.Assert().Equal(data.ErrCanceled, err.Error())
+	s.Assert().Less(int64(time.Since(start)), int64(5*time.Second))
+
+	_ = lock.Rollback()
+	u, _ := s.Store.GetUserById(100)
+	s.Assert().Equal("A", u.FirstName)
+}
+
+// untimed returns a copy of a record, a pointer to one or a slice of them with their timestamps zeroed,
+// so that records can be compared with literals regardless of when the fixtures were loaded
+func untimed(v interface{}) interface{} {
+	return zeroTimestamps(reflect.ValueOf(v)).Interface()
+}
+
+func zeroTimestamps(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(zeroTimestamps(v.Elem()))
+
+		return p
+	case reflect.Slice:
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(zeroTimestamps(v.Index(i)))
+		}
+
+		return c
+	case reflect.Map:
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, zeroTimestamps(v.MapIndex(k)))
+		}
+
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+
+		for _, name := range []string{"CreatedAt", "UpdatedAt", "LinkedAt"} {
+			if f := c.FieldByName(name); f.IsValid() {
+				f.Set(reflect.Zero(f.Type()))
+			}
+		}
+
+		return c
+	}
+
+	return v
+}
+
 func TestStoreTestSuite(t *testing.T) {
 	suite.Run(t, new(StoreTestSuite))
 }
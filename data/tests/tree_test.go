@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+)
+
+// int64Ptr returns a pointer to i
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+// buildTree nests group 203 under 202 and 202 under 201
+func (s *StoreTestSuite) buildTree() {
+	s.Require().NoError(s.Store.MoveGroup(202, int64Ptr(201)))
+	s.Require().NoError(s.Store.MoveGroup(203, int64Ptr(202)))
+}
+
+func (s *StoreTestSuite) TestMoveGroup() {
+	s.buildTree()
+
+	g, _ := s.Store.GetGroupById(203)
+	s.Assert().Equal(&data.Group{Id: 203, Name: "G", ParentId: int64Ptr(202), Version: 2}, untimed(g))
+
+	err := s.Store.MoveGroup(201, int64Ptr(203))
+	s.Assert().True(errors.Is(err, data.ErrConflict))
+	s.Assert().Equal(data.ErrGroupCycle, err.Error())
+
+	err = s.Store.MoveGroup(201, int64Ptr(201))
+	s.Assert().True(errors.Is(err, data.ErrConflict))
+
+	err = s.Store.MoveGroup(201, int64Ptr(1000))
+	s.Assert().True(errors.Is(err, data.ErrForeignKey))
+	s.Assert().Equal(data.ErrParentGroupDNE, err.Error())
+
+	err = s.Store.MoveGroup(1000, int64Ptr(201))
+	s.Assert().True(errors.Is(err, data.ErrNotFound))
+
+	err = s.Store.MoveGroup(202, nil)
+	s.Require().NoError(err)
+
+	g, _ = s.Store.GetGroupById(202)
+	s.Assert().Nil(g.ParentId)
+
+	// the subtree moves with its root
+	g, _ = s.Store.GetGroupById(203)
+	s.Assert().Equal(int64Ptr(202), g.ParentId)
+}
+
+func (s *StoreTestSuite) TestCreateGroupWithParent() {
+	g, err := s.Store.CreateGroup(&data.Group{Name: "child", ParentId: int64Ptr(201)})
+	s.Require().NoError(err)
+
+	g, _ = s.Store.GetGroupById(g.Id)
+	s.Assert().Equal(int64Ptr(201), g.ParentId)
+
+	_, err = s.Store.CreateGroup(&data.Group{Name: "orphan", ParentId: int64Ptr(1000)})
+	s.Assert().True(errors.Is(err, data.ErrForeignKey))
+	s.Assert().Equal(data.ErrParentGroupDNE, err.Error())
+}
+
+func (s *StoreTestSuite) TestPurgeGroupWithChildren() {
+	s.buildTree()
+
+	err := s.Store.PurgeGroup(202, data.DeleteCascade)
+	s.Assert().True(errors.Is(err, data.ErrConflict))
+	s.Assert().Equal(data.ErrGroupHasChildren, err.Error())
+
+	g, _ := s.Store.GetGroupById(202)
+	s.Assert().NotNil(g)
+
+	// a leaf group can be purged
+	s.Require().NoError(s.Store.PurgeGroup(203, data.DeleteCascade))
+	s.Require().NoError(s.Store.PurgeGroup(202, data.DeleteCascade))
+}
+
+func (s *StoreTestSuite) TestListUsersInGroupTree() {
+	s.buildTree()
+
+	page, err := s.Store.ListUsersInGroupTree(201, data.ListOptions{Count: true})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{201, 202, 203}, userIds(page.Users))
+	s.Assert().Equal(int64(3), *page.Total)
+
+	page, _ = s.Store.ListUsersInGroupTree(202, data.ListOptions{})
+	s.Assert().Equal([]int64{202, 203}, userIds(page.Users))
+
+	// traversal stops at soft-deleted groups
+	_ = s.Store.DeleteGroup(202)
+
+	page, _ = s.Store.ListUsersInGroupTree(201, data.ListOptions{})
+	s.Assert().Equal([]int64{201, 202}, userIds(page.Users))
+}
+
+func (s *StoreTestSuite) TestListEffectiveGroupsByUserId() {
+	s.buildTree()
+
+	page, err := s.Store.ListEffectiveGroupsByUserId(203, data.ListOptions{})
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{201, 202, 203}, groupIds(page.Groups))
+
+	page, _ = s.Store.ListEffectiveGroupsByUserId(202, data.ListOptions{})
+	s.Assert().Equal([]int64{201, 202}, groupIds(page.Groups))
+
+	page, _ = s.Store.ListEffectiveGroupsByUserId(100, data.ListOptions{})
+	s.Assert().Empty(page.Groups)
+
+	_ = s.Store.DeleteGroup(202)
+
+	page, _ = s.Store.ListEffectiveGroupsByUserId(203, data.ListOptions{})
+	s.Assert().Equal([]int64{203}, groupIds(page.Groups))
+}
+
+func userIds(users []data.User) []int64 {
+	ids := make([]int64, len(users))
+
+	for i, u := range users {
+		ids[i] = u.Id
+	}
+
+	return ids
+}
+
+func groupIds(groups []data.Group) []int64 {
+	ids := make([]int64, len(groups))
+
+	for i, g := range groups {
+		ids[i] = g.Id
+	}
+
+	return ids
+}
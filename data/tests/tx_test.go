@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+)
+
+func (s *StoreTestSuite) TestWithTxCommits() {
+	var user *data.User
+	var group *data.Group
+
+	err := s.Store.WithTx(context.Background(), func(tx *data.Store) error {
+		var err error
+
+		if user, err = tx.CreateUser(&data.User{FirstName: "foo", LastName: "bar"}); err != nil {
+			return err
+		}
+
+		if group, err = tx.CreateGroup(&data.Group{Name: "foo"}); err != nil {
+			return err
+		}
+
+		return tx.LinkGroupToUser(group.Id, user.Id)
+	})
+	s.Require().NoError(err)
+
+	users, _ := s.Store.GetUsersByGroupId(group.Id)
+	s.Assert().Equal([]data.User{*user}, users)
+}
+
+func (s *StoreTestSuite) TestWithTxRollsBackOnError() {
+	var user *data.User
+	failure := errors.New("failure")
+
+	err := s.Store.WithTx(context.Background(), func(tx *data.Store) error {
+		user, _ = tx.CreateUser(&data.User{FirstName: "foo", LastName: "bar"})
+		_ = tx.UpdateUser(100, &data.User{FirstName: "abc"}, "FirstName")
+		return failure
+	})
+	s.Assert().Equal(failure, err)
+
+	retrieved, _ := s.Store.GetUserById(user.Id)
+	s.Assert().Nil(retrieved)
+
+	retrieved, _ = s.Store.GetUserById(100)
+	s.Assert().Equal("A", retrieved.FirstName)
+}
+
+func (s *StoreTestSuite) TestWithTxRollsBackOnPanic() {
+	var user *data.User
+
+	s.Assert().Panics(func() {
+		_ = s.Store.WithTx(context.Background(), func(tx *data.Store) error {
+			user, _ = tx.CreateUser(&data.User{FirstName: "foo", LastName: "bar"})
+			panic("failure")
+		})
+	})
+
+	retrieved, _ := s.Store.GetUserById(user.Id)
+	s.Assert().Nil(retrieved)
+}
+
+func (s *StoreTestSuite) TestWithTxSavepoint() {
+	var outer, inner *data.User
+	failure := errors.New("failure")
+
+	err := s.Store.WithTx(context.Background(), func(tx *data.Store) error {
+		outer, _ = tx.CreateUser(&data.User{FirstName: "outer", LastName: "bar"})
+
+		err := tx.WithTx(context.Background(), func(tx *data.Store) error {
+			inner, _ = tx.CreateUser(&data.User{FirstName: "inner", LastName: "bar"})
+			return failure
+		})
+		s.Assert().Equal(failure, err)
+
+		return nil
+	})
+	s.Require().NoError(err)
+
+	retrieved, _ := s.Store.GetUserById(outer.Id)
+	s.Assert().EqualValues(outer, retrieved)
+
+	retrieved, _ = s.Store.GetUserById(inner.Id)
+	s.Assert().Nil(retrieved)
+}
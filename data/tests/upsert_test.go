@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func (s *StoreTestSuite) TestUpsertUser() {
+	u, err := s.Store.UpsertUser(&data.User{FirstName: "Bo", LastName: "Peep", Email: stringPtr("bo@peep.com")})
+	s.Require().NoError(err)
+	s.Assert().NotZero(u.Id)
+	s.Assert().Equal(int64(1), u.Version)
+
+	id := u.Id
+
+	u, err = s.Store.UpsertUser(&data.User{FirstName: "Bo", LastName: "Jackson", Email: stringPtr("BO@peep.com")})
+	s.Require().NoError(err)
+	s.Assert().Equal(id, u.Id)
+	s.Assert().Equal(int64(2), u.Version)
+
+	// an unchanged user keeps its version
+	u, err = s.Store.UpsertUser(&data.User{FirstName: "Bo", LastName: "Jackson", Email: stringPtr("BO@peep.com")})
+	s.Require().NoError(err)
+	s.Assert().Equal(id, u.Id)
+	s.Assert().Equal(int64(2), u.Version)
+
+	stored, _ := s.Store.GetUserById(id)
+	s.Assert().Equal("Jackson", stored.LastName)
+
+	_, err = s.Store.UpsertUser(&data.User{FirstName: "Bo", LastName: "Peep"})
+	var validationErr *data.ValidationError
+	s.Require().True(errors.As(err, &validationErr))
+	s.Assert().Equal("email", validationErr.Violations[0].Field)
+
+	_ = s.Store.DeleteUser(id)
+
+	_, err = s.Store.UpsertUser(&data.User{FirstName: "Bo", LastName: "Peep", Email: stringPtr("bo@peep.com")})
+	var existsErr *data.AlreadyExistsError
+	s.Require().True(errors.As(err, &existsErr))
+	s.Assert().Equal("email", existsErr.Field)
+}
+
+func (s *StoreTestSuite) TestUpsertGroup() {
+	g, err := s.Store.UpsertGroup(&data.Group{Name: "a"})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(100), g.Id)
+	s.Assert().Equal("a", g.Name)
+	s.Assert().Equal(int64(2), g.Version)
+
+	// names are only unique among siblings
+	child, err := s.Store.UpsertGroup(&data.Group{Name: "A", ParentId: int64Ptr(101)})
+	s.Require().NoError(err)
+	s.Assert().NotEqual(int64(100), child.Id)
+
+	again, err := s.Store.UpsertGroup(&data.Group{Name: "A", ParentId: int64Ptr(101)})
+	s.Require().NoError(err)
+	s.Assert().Equal(child.Id, again.Id)
+	s.Assert().Equal(int64(1), again.Version)
+
+	_, err = s.Store.CreateGroup(&data.Group{Name: "b"})
+	var existsErr *data.AlreadyExistsError
+	s.Require().True(errors.As(err, &existsErr))
+	s.Assert().Equal("name", existsErr.Field)
+}
+
+func (s *StoreTestSuite) TestNameOfDeletedGroup() {
+	s.Require().NoError(s.Store.DeleteGroup(100))
+
+	// a soft-deleted group does not hold on to its name
+	g, err := s.Store.CreateGroup(&data.Group{Name: "a"})
+	s.Require().NoError(err)
+	s.Assert().NotEqual(int64(100), g.Id)
+
+	upserted, err := s.Store.UpsertGroup(&data.Group{Name: "A"})
+	s.Require().NoError(err)
+	s.Assert().Equal(g.Id, upserted.Id)
+
+	err = s.Store.RestoreGroup(100)
+	var existsErr *data.AlreadyExistsError
+	s.Require().True(errors.As(err, &existsErr))
+	s.Assert().Equal("name", existsErr.Field)
+}
+
+func (s *StoreTestSuite) TestEnsureLinked() {
+	err := s.Store.EnsureLinked(200, 200, data.RoleAdmin)
+	s.Require().NoError(err)
+
+	err = s.Store.EnsureLinked(200, 200)
+	s.Require().NoError(err)
+
+	m, _ := s.Store.GetMembership(200, 200)
+	s.Assert().Equal(data.RoleAdmin, m.Role)
+
+	err = s.Store.EnsureLinked(200, 200, data.RoleOwner)
+	s.Require().NoError(err)
+
+	m, _ = s.Store.GetMembership(200, 200)
+	s.Assert().Equal(data.RoleOwner, m.Role)
+
+	err = s.Store.EnsureLinked(1000, 200)
+	s.Assert().True(errors.Is(err, data.ErrForeignKey))
+
+	_ = s.Store.DeleteGroup(201)
+
+	err = s.Store.EnsureLinked(201, 201)
+	s.Assert().True(errors.Is(err, data.ErrForeignKey))
+}
@@ -0,0 +1,134 @@
+package data
+
+import (
+	"context"
+
+	"github.com/gocraft/dbr/v2"
+)
+
+// the ids of a group and its descendants, stopping at soft-deleted groups
+const groupSubtree = `with recursive subtree as (
+	select id from "group" where id = ? and deleted_at is null
+	union
+	select g.id from "group" g join subtree t on g.parent_id = t.id where g.deleted_at is null
+) select id from subtree`
+
+// the ids of the groups selected by a subquery and their ancestors, stopping at soft-deleted groups
+const groupAncestors = `with recursive ancestors as (
+	select id, parent_id from (?) direct
+	union
+	select g.id, g.parent_id from "group" g join ancestors a on g.id = a.parent_id where g.deleted_at is null
+) select id from ancestors`
+
+// counts the groups on the path from a group up to the root that have a given id, including soft-deleted groups
+const countInPath = `with recursive path as (
+	select id, parent_id from "group" where id = ?
+	union
+	select g.id, g.parent_id from "group" g join path p on g.id = p.parent_id
+) select count(*) from path where id = ?`
+
+// the key of the advisory lock that serializes group moves, so that concurrent moves cannot create a cycle
+const groupTreeLock = 20191109
+
+// MoveGroup moves a group and its descendants under another group, or to the root when parentId is nil.
+// Moving a group under itself or one of its descendants fails with an error that Is ErrConflict
+func (s *Store) MoveGroup(id int64, parentId *int64) error {
+	return s.MoveGroupContext(context.Background(), id, parentId)
+}
+
+// MoveGroupContext moves a group and its descendants under another group, aborting if ctx is done
+func (s *Store) MoveGroupContext(ctx context.Context, id int64, parentId *int64) error {
+	err := s.WithTx(ctx, func(tx *Store) error {
+		if _, err := tx.tx.ExecContext(ctx, "select pg_advisory_xact_lock($1)", groupTreeLock); err != nil {
+			return err
+		}
+
+		if parentId != nil {
+			if err := tx.checkParent(ctx, id, *parentId); err != nil {
+				return err
+			}
+		}
+
+		return tx.update(ctx, "group", id, []string{"ParentId"}, set{"ParentId", "parent_id", parentId})
+	})
+
+	return NewError(err)
+}
+
+// checkParent returns an error if a group cannot be moved under a parent,
+// because the parent does not exist or is the group itself or one of its descendants
+func (s *Store) checkParent(ctx context.Context, id int64, parentId int64) error {
+	var parents int
+
+	err := s.db.
+		Select("count(*)").
+		From(quotes("group")).
+		Where("id = ? and deleted_at is null", parentId).
+		LoadOneContext(ctx, &parents)
+
+	if err != nil {
+		return err
+	}
+
+	if parents == 0 {
+		return errParentGroupDNE
+	}
+
+	var cycles int
+
+	if err := s.db.SelectBySql(countInPath, parentId, id).LoadOneContext(ctx, &cycles); err != nil {
+		return err
+	}
+
+	if cycles > 0 {
+		return errGroupCycle
+	}
+
+	return nil
+}
+
+// ListUsersInGroupTree returns a page of the users that belong to a group or any of its descendants
+func (s *Store) ListUsersInGroupTree(groupId int64, opts ListOptions) (*UserList, error) {
+	return s.ListUsersInGroupTreeContext(context.Background(), groupId, opts)
+}
+
+// ListUsersInGroupTreeContext returns a page of the users that belong to a group or any of its descendants,
+// aborting if ctx is done
+func (s *Store) ListUsersInGroupTreeContext(ctx context.Context, groupId int64, opts ListOptions) (*UserList, error) {
+	users := []User{}
+	stmt := s.selectJunctionIn(s.db, dbr.Expr(groupSubtree, groupId), usersByGroup)
+	applySince(stmt, "group_user.linked_at", opts)
+	next, total, err := s.loadPage(ctx, stmt, userSchema, opts, &users)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return &UserList{Users: users, NextCursor: next, Total: total}, nil
+}
+
+// ListEffectiveGroupsByUserId returns a page of the groups that contain a user, directly or through a descendant
+func (s *Store) ListEffectiveGroupsByUserId(userId int64, opts ListOptions) (*GroupList, error) {
+	return s.ListEffectiveGroupsByUserIdContext(context.Background(), userId, opts)
+}
+
+// ListEffectiveGroupsByUserIdContext returns a page of the groups that contain a user, directly or through a descendant,
+// aborting if ctx is done
+func (s *Store) ListEffectiveGroupsByUserIdContext(ctx context.Context, userId int64, opts ListOptions) (*GroupList, error) {
+	groups := []Group{}
+	direct := s.selectJunction(s.db, userId, groupsByUser)
+	applySince(direct, "group_user.linked_at", opts)
+
+	stmt := s.db.
+		Select(`"group".*`).
+		From(quotes("group")).
+		Where(`"group".id in (?)`, dbr.Expr(groupAncestors, direct))
+
+	next, total, err := s.loadPage(ctx, stmt, groupSchema, opts, &groups)
+
+	if err != nil {
+		return nil, NewError(err)
+	}
+
+	return &GroupList{Groups: groups, NextCursor: next, Total: total}, nil
+}
@@ -0,0 +1,76 @@
+package data
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithTx runs fn inside a database transaction. Every operation performed on
+// the Store passed to fn is part of that transaction, which is committed when
+// fn returns nil and rolled back when fn returns an error or panics.
+// Calling WithTx on a transactional Store nests fn inside a savepoint, so a
+// failing inner call only rolls back its own work.
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s.tx != nil {
+		return s.withSavepoint(ctx, fn)
+	}
+
+	tx, err := s.sess.BeginTx(ctx, nil)
+
+	if err != nil {
+		return NewError(err)
+	}
+
+	txStore := &Store{
+		db:       tx,
+		tx:       tx,
+		strict:   s.strict,
+		validate: s.validate,
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(txStore); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return NewError(tx.Commit())
+}
+
+func (s *Store) withSavepoint(ctx context.Context, fn func(tx *Store) error) error {
+	name := fmt.Sprintf("sp_%d", s.depth+1)
+
+	if _, err := s.tx.ExecContext(ctx, "savepoint "+name); err != nil {
+		return NewError(err)
+	}
+
+	txStore := &Store{
+		db:       s.tx,
+		tx:       s.tx,
+		depth:    s.depth + 1,
+		strict:   s.strict,
+		validate: s.validate,
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = s.tx.ExecContext(ctx, "rollback to savepoint "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(txStore); err != nil {
+		_, _ = s.tx.ExecContext(ctx, "rollback to savepoint "+name)
+		return err
+	}
+
+	_, err := s.tx.ExecContext(ctx, "release savepoint "+name)
+
+	return NewError(err)
+}
@@ -0,0 +1,195 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gocraft/dbr/v2"
+	"github.com/gocraft/dbr/v2/dialect"
+	"strings"
+)
+
+// uniqueKey is a unique index that identifies a resource by a natural key
+type uniqueKey struct {
+	// the field that is reported as taken when the key conflicts with a soft-deleted resource
+	field string
+
+	// the indexed expressions, which are the conflict target of upserts
+	target string
+
+	// the predicate of a partial index, which the conflict target must repeat for the index to be inferred
+	predicate string
+
+	// the condition that matches the row with the key, taking the key values as arguments
+	where string
+}
+
+var userEmailKey = uniqueKey{
+	field:  "email",
+	target: "lower(email)",
+	where:  "lower(email) = lower(?)",
+}
+
+var groupNameKey = uniqueKey{
+	field:     "name",
+	target:    "coalesce(parent_id, 0), lower(name)",
+	predicate: "deleted_at is null",
+	where:     "coalesce(parent_id, 0) = coalesce(?, 0) and lower(name) = lower(?)",
+}
+
+// UpsertUser creates a user, or updates the user with the same email, ignoring case.
+// A user whose fields are unchanged is left as is, so repeating an upsert does not move the user to a new version.
+// The user is set to its stored state, including its Id, version and timestamps
+func (s *Store) UpsertUser(u *User) (*User, error) {
+	return s.UpsertUserContext(context.Background(), u)
+}
+
+// UpsertUserContext creates or updates a user by email, aborting if ctx is done
+func (s *Store) UpsertUserContext(ctx context.Context, u *User) (*User, error) {
+	if err := s.validate.Struct(u); err != nil {
+		return nil, NewError(err)
+	}
+
+	if u.Email == nil {
+		return nil, requiredError("email")
+	}
+
+	if err := s.upsert(ctx, "user", u, userResource.columns, setColumns(userResource.sets(u)), userEmailKey, *u.Email); err != nil {
+		return nil, NewError(err)
+	}
+
+	return u, nil
+}
+
+// UpsertGroup creates a group, or updates the group with the same name and parent, ignoring the case of the name.
+// A group whose fields are unchanged is left as is, so repeating an upsert does not move the group to a new version.
+// The group is set to its stored state, including its Id, version and timestamps
+func (s *Store) UpsertGroup(g *Group) (*Group, error) {
+	return s.UpsertGroupContext(context.Background(), g)
+}
+
+// UpsertGroupContext creates or updates a group by name and parent, aborting if ctx is done
+func (s *Store) UpsertGroupContext(ctx context.Context, g *Group) (*Group, error) {
+	if err := s.validate.Struct(g); err != nil {
+		return nil, NewError(err)
+	}
+
+	if err := s.upsert(ctx, "group", g, groupResource.columns, setColumns(groupResource.sets(g)), groupNameKey, g.ParentId, g.Name); err != nil {
+		return nil, NewError(err)
+	}
+
+	return g, nil
+}
+
+// EnsureLinked links a group to a user like LinkGroupToUser, but succeeds if they are already linked.
+// When a role is given, an existing membership is changed to that role, otherwise it keeps its role
+func (s *Store) EnsureLinked(groupId int64, userId int64, role ...Role) error {
+	return s.EnsureLinkedContext(context.Background(), groupId, userId, role...)
+}
+
+// EnsureLinkedContext links a group to a user unless they are already linked, aborting if ctx is done
+func (s *Store) EnsureLinkedContext(ctx context.Context, groupId int64, userId int64, role ...Role) error {
+	r := memberRole(role)
+
+	if err := s.validateRole(r); err != nil {
+		return err
+	}
+
+	action := "do nothing"
+
+	if len(role) > 0 {
+		action = "do update set role = excluded.role"
+	}
+
+	result, err := s.db.
+		InsertBySql(
+			`insert into group_user (group_id, user_id, role) select g.id, u.id, ? from "group" g, "user" u
+			where g.id = ? and u.id = ? and g.deleted_at is null and u.deleted_at is null
+			on conflict (group_id, user_id) `+action,
+			r, groupId, userId,
+		).
+		ExecContext(ctx)
+
+	if err = affectedOne(result, err); !errors.Is(err, ErrNotFound) {
+		return NewError(err)
+	}
+
+	// nothing is written both when the membership exists and when the group or user does not
+	m, err := s.GetMembershipContext(ctx, groupId, userId)
+
+	if errors.Is(err, ErrNotFound) || (err == nil && m == nil) {
+		return errGroupOrUserDNE
+	}
+
+	return err
+}
+
+// upsert inserts a record, or updates the update columns of the row that has the same key.
+// The row is only updated if it is not soft-deleted and one of the update columns changes.
+// The record is set to the stored row, and keyValues are the arguments of the key's condition
+func (s *Store) upsert(ctx context.Context, table string, record interface{}, columns []string, updates []string, key uniqueKey, keyValues ...interface{}) error {
+	buf := dbr.NewBuffer()
+
+	if err := dbr.InsertInto(table).Columns(columns...).Record(record).Build(dialect.PostgreSQL, buf); err != nil {
+		return err
+	}
+
+	t := quotes(table)
+	sets := make([]string, len(updates))
+	current := make([]string, len(updates))
+	excluded := make([]string, len(updates))
+
+	for i, col := range updates {
+		sets[i] = fmt.Sprintf("%s = excluded.%s", col, col)
+		current[i] = t + "." + col
+		excluded[i] = "excluded." + col
+	}
+
+	target := "(" + key.target + ")"
+
+	if key.predicate != "" {
+		target += " where " + key.predicate
+	}
+
+	query := fmt.Sprintf(
+		`%s on conflict %s do update set %s, version = %s.version + 1, updated_at = now()
+		where %s.deleted_at is null and (%s) is distinct from (%s) returning %s`,
+		buf.String(), target, strings.Join(sets, ", "), t,
+		t, strings.Join(current, ", "), strings.Join(excluded, ", "), strings.Join(generatedColumns, ", "),
+	)
+
+	var rows []generated
+
+	if err := s.db.InsertBySql(query, buf.Value()...).LoadContext(ctx, &rows); err != nil {
+		return err
+	}
+
+	if len(rows) == 1 {
+		setGenerated(record, rows[0])
+		return nil
+	}
+
+	// nothing is returned when the row with the key is unchanged or soft-deleted
+	_, count, err := s.getWhere(ctx, table, record, key.where, keyValues...)
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return &AlreadyExistsError{Field: key.field}
+	}
+
+	return nil
+}
+
+// setColumns returns the columns of sets
+func setColumns(sets []set) []string {
+	columns := make([]string, len(sets))
+
+	for i, s := range sets {
+		columns[i] = s.Col
+	}
+
+	return columns
+}
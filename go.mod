@@ -1,18 +1,38 @@
 module github.com/brietsparks/xcrud
 
-go 1.13
+go 1.25.0
 
 require (
-	github.com/davecgh/go-spew v1.1.1
-	github.com/go-playground/universal-translator v0.17.0 // indirect
 	github.com/gocraft/dbr/v2 v2.6.3
 	github.com/golang-migrate/migrate/v4 v4.7.0
+	github.com/graph-gophers/graphql-go v1.10.3
 	github.com/joho/godotenv v1.3.0
-	github.com/leodido/go-urn v1.2.0 // indirect
 	github.com/lib/pq v1.2.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/stretchr/testify v1.4.0
 	github.com/urfave/cli v1.22.1
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260904194346-d0f1323225a4
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.12
 	gopkg.in/go-playground/validator.v9 v9.30.0
 	gopkg.in/testfixtures.v2 v2.6.0
+	gopkg.in/yaml.v2 v2.2.2
+)
+
+require (
+	github.com/cpuguy83/go-md2man/v2 v2.0.0-20190314233015-f79a8a8ca69d // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-playground/locales v0.13.0 // indirect
+	github.com/go-playground/universal-translator v0.17.0 // indirect
+	github.com/hashicorp/errwrap v1.0.0 // indirect
+	github.com/hashicorp/go-multierror v1.0.0 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/leodido/go-urn v1.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/russross/blackfriday/v2 v2.0.1 // indirect
+	github.com/shurcooL/sanitized_anchor_name v1.0.0 // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 )
@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"net/http"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// Store is the part of data.Store that the resolvers read through
+type Store interface {
+	GetUserByIdContext(ctx context.Context, id int64) (*data.User, error)
+	GetGroupByIdContext(ctx context.Context, id int64) (*data.Group, error)
+	GetMembershipContext(ctx context.Context, groupId int64, userId int64) (*data.Membership, error)
+	ListUsersContext(ctx context.Context, opts data.ListOptions) (*data.UserList, error)
+	ListGroupsContext(ctx context.Context, opts data.ListOptions) (*data.GroupList, error)
+}
+
+// Loader looks up pages of the users of groups and the groups of users, such as a data.Loader that batches the lookups
+type Loader interface {
+	ListUsersByGroupId(ctx context.Context, groupId int64, opts data.ListOptions) (*data.UserList, error)
+	ListGroupsByUserId(ctx context.Context, userId int64, opts data.ListOptions) (*data.GroupList, error)
+}
+
+// the number of fields that are resolved at once, which bounds the size of the batches of a Loader
+const maxParallelism = 100
+
+// the deepest nesting of fields that a query may have, which bounds how far it can fan out through relationships
+const maxDepth = 10
+
+// loaderKey is the context key of the Loader of a request
+type loaderKey struct{}
+
+// Handler serves GraphQL queries of users, groups and memberships
+type Handler struct {
+	relay     *relay.Handler
+	newLoader func() Loader
+}
+
+// NewHandler creates a Handler that reads through store, and creates a Loader with newLoader for each request
+// so that the relationships of the resolved users and groups are looked up in batches
+func NewHandler(store Store, newLoader func() Loader) *Handler {
+	s := graphql.MustParseSchema(schema, &resolver{store: store}, graphql.MaxParallelism(maxParallelism), graphql.MaxDepth(maxDepth))
+
+	return &Handler{relay: &relay.Handler{Schema: s}, newLoader: newLoader}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), loaderKey{}, h.newLoader())
+	h.relay.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// loaderOf returns the Loader of a request
+func loaderOf(ctx context.Context) Loader {
+	return ctx.Value(loaderKey{}).(Loader)
+}
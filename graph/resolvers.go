@@ -0,0 +1,334 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/graph-gophers/graphql-go"
+	"strconv"
+)
+
+// resolver resolves the fields of the Query type
+type resolver struct {
+	store Store
+}
+
+type listArgs struct {
+	Limit  *int32
+	Cursor *string
+}
+
+func (a listArgs) options() data.ListOptions {
+	var opts data.ListOptions
+
+	if a.Limit != nil {
+		opts.Limit = int(*a.Limit)
+	}
+
+	if a.Cursor != nil {
+		opts.Cursor = *a.Cursor
+	}
+
+	return opts
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ Id graphql.ID }) (*userResolver, error) {
+	id, err := parseId(args.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.user(ctx, id)
+}
+
+func (r *resolver) Group(ctx context.Context, args struct{ Id graphql.ID }) (*groupResolver, error) {
+	id, err := parseId(args.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.group(ctx, id)
+}
+
+func (r *resolver) Membership(ctx context.Context, args struct{ GroupId, UserId graphql.ID }) (*membershipResolver, error) {
+	groupId, err := parseId(args.GroupId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := parseId(args.UserId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := r.store.GetMembershipContext(ctx, groupId, userId)
+
+	if err != nil || m == nil {
+		return nil, err
+	}
+
+	return &membershipResolver{root: r, m: m}, nil
+}
+
+func (r *resolver) Users(ctx context.Context, args listArgs) (*userListResolver, error) {
+	users, err := r.store.ListUsersContext(ctx, args.options())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &userListResolver{list: users}, nil
+}
+
+func (r *resolver) Groups(ctx context.Context, args listArgs) (*groupListResolver, error) {
+	groups, err := r.store.ListGroupsContext(ctx, args.options())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &groupListResolver{list: groups}, nil
+}
+
+// user resolves a user by id, which is null if the user does not exist
+func (r *resolver) user(ctx context.Context, id int64) (*userResolver, error) {
+	u, err := r.store.GetUserByIdContext(ctx, id)
+
+	if err != nil || u == nil {
+		return nil, err
+	}
+
+	return &userResolver{u: *u}, nil
+}
+
+// group resolves a group by id, which is null if the group does not exist
+func (r *resolver) group(ctx context.Context, id int64) (*groupResolver, error) {
+	g, err := r.store.GetGroupByIdContext(ctx, id)
+
+	if err != nil || g == nil {
+		return nil, err
+	}
+
+	return &groupResolver{g: *g}, nil
+}
+
+type userResolver struct {
+	u data.User
+}
+
+func (r *userResolver) Id() graphql.ID {
+	return formatId(r.u.Id)
+}
+
+func (r *userResolver) FirstName() string {
+	return r.u.FirstName
+}
+
+func (r *userResolver) LastName() string {
+	return r.u.LastName
+}
+
+func (r *userResolver) Email() *string {
+	return r.u.Email
+}
+
+func (r *userResolver) Username() *string {
+	return r.u.Username
+}
+
+func (r *userResolver) Version() int32 {
+	return int32(r.u.Version)
+}
+
+func (r *userResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.u.CreatedAt}
+}
+
+func (r *userResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.u.UpdatedAt}
+}
+
+// Groups resolves a page of the groups of the user through the Loader of the request,
+// which looks it up together with the pages of the other users being resolved
+func (r *userResolver) Groups(ctx context.Context, args listArgs) (*groupListResolver, error) {
+	groups, err := loaderOf(ctx).ListGroupsByUserId(ctx, r.u.Id, args.options())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &groupListResolver{list: groups}, nil
+}
+
+type groupResolver struct {
+	g data.Group
+}
+
+func (r *groupResolver) Id() graphql.ID {
+	return formatId(r.g.Id)
+}
+
+func (r *groupResolver) Name() string {
+	return r.g.Name
+}
+
+func (r *groupResolver) ParentId() *graphql.ID {
+	if r.g.ParentId == nil {
+		return nil
+	}
+
+	id := formatId(*r.g.ParentId)
+
+	return &id
+}
+
+func (r *groupResolver) Version() int32 {
+	return int32(r.g.Version)
+}
+
+func (r *groupResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.g.CreatedAt}
+}
+
+func (r *groupResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.g.UpdatedAt}
+}
+
+// Users resolves a page of the users of the group through the Loader of the request,
+// which looks it up together with the pages of the other groups being resolved
+func (r *groupResolver) Users(ctx context.Context, args listArgs) (*userListResolver, error) {
+	users, err := loaderOf(ctx).ListUsersByGroupId(ctx, r.g.Id, args.options())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &userListResolver{list: users}, nil
+}
+
+type membershipResolver struct {
+	root *resolver
+	m    *data.Membership
+}
+
+func (r *membershipResolver) Group(ctx context.Context) (*groupResolver, error) {
+	g, err := r.root.group(ctx, r.m.GroupId)
+
+	if err == nil && g == nil {
+		return nil, data.ErrNotFound
+	}
+
+	return g, err
+}
+
+func (r *membershipResolver) User(ctx context.Context) (*userResolver, error) {
+	u, err := r.root.user(ctx, r.m.UserId)
+
+	if err == nil && u == nil {
+		return nil, data.ErrNotFound
+	}
+
+	return u, err
+}
+
+func (r *membershipResolver) Role() string {
+	return string(r.m.Role)
+}
+
+func (r *membershipResolver) Attributes() *JSON {
+	if r.m.Attributes == nil {
+		return nil
+	}
+
+	attributes := JSON(r.m.Attributes)
+
+	return &attributes
+}
+
+func (r *membershipResolver) LinkedAt() graphql.Time {
+	return graphql.Time{Time: r.m.LinkedAt}
+}
+
+type userListResolver struct {
+	list *data.UserList
+}
+
+func (r *userListResolver) Users() []*userResolver {
+	return userResolvers(r.list.Users)
+}
+
+func (r *userListResolver) NextCursor() string {
+	return r.list.NextCursor
+}
+
+type groupListResolver struct {
+	list *data.GroupList
+}
+
+func (r *groupListResolver) Groups() []*groupResolver {
+	return groupResolvers(r.list.Groups)
+}
+
+func (r *groupListResolver) NextCursor() string {
+	return r.list.NextCursor
+}
+
+func userResolvers(users []data.User) []*userResolver {
+	resolvers := make([]*userResolver, len(users))
+
+	for i, u := range users {
+		resolvers[i] = &userResolver{u: u}
+	}
+
+	return resolvers
+}
+
+func groupResolvers(groups []data.Group) []*groupResolver {
+	resolvers := make([]*groupResolver, len(groups))
+
+	for i, g := range groups {
+		resolvers[i] = &groupResolver{g: g}
+	}
+
+	return resolvers
+}
+
+// JSON is the JSON scalar, which holds a JSON object
+type JSON map[string]interface{}
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	object, ok := input.(map[string]interface{})
+
+	if !ok {
+		return fmt.Errorf("JSON must be an object, not %T", input)
+	}
+
+	*j = object
+
+	return nil
+}
+
+var errInvalidId = errors.New("invalid id")
+
+func parseId(id graphql.ID) (int64, error) {
+	i, err := strconv.ParseInt(string(id), 10, 64)
+
+	if err != nil {
+		return 0, errInvalidId
+	}
+
+	return i, nil
+}
+
+func formatId(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
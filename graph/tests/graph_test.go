@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/brietsparks/xcrud/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeStore returns canned results.
+// Methods that a test does not expect panic through the nil embedded Store
+type fakeStore struct {
+	graph.Store
+
+	users  map[int64]*data.User
+	groups map[int64]*data.Group
+	err    error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users: map[int64]*data.User{
+			1: {Id: 1, FirstName: "Bo", LastName: "Peep", Version: 2},
+			2: {Id: 2, FirstName: "Jack", LastName: "Horner", Version: 1},
+		},
+		groups: map[int64]*data.Group{
+			10: {Id: 10, Name: "A", Version: 1},
+			11: {Id: 11, Name: "B", ParentId: int64Ptr(10), Version: 1},
+		},
+	}
+}
+
+func (f *fakeStore) GetUserByIdContext(ctx context.Context, id int64) (*data.User, error) {
+	return f.users[id], f.err
+}
+
+func (f *fakeStore) GetGroupByIdContext(ctx context.Context, id int64) (*data.Group, error) {
+	return f.groups[id], f.err
+}
+
+func (f *fakeStore) GetMembershipContext(ctx context.Context, groupId int64, userId int64) (*data.Membership, error) {
+	return &data.Membership{GroupId: groupId, UserId: userId, Role: data.RoleAdmin, Attributes: data.Attributes{"title": "lead"}}, nil
+}
+
+func (f *fakeStore) ListUsersContext(ctx context.Context, opts data.ListOptions) (*data.UserList, error) {
+	return &data.UserList{Users: []data.User{*f.users[1]}, NextCursor: "next"}, f.err
+}
+
+// fakeLoader links both users to group 10 and user 2 to group 11, and records the ids and options it looks up
+type fakeLoader struct {
+	mu      sync.Mutex
+	userIds []int64
+	opts    []data.ListOptions
+}
+
+func (l *fakeLoader) ListUsersByGroupId(ctx context.Context, groupId int64, opts data.ListOptions) (*data.UserList, error) {
+	l.mu.Lock()
+	l.opts = append(l.opts, opts)
+	l.mu.Unlock()
+
+	if groupId == 10 {
+		return &data.UserList{Users: []data.User{{Id: 1}, {Id: 2}}}, nil
+	}
+
+	return &data.UserList{Users: []data.User{{Id: 2}}}, nil
+}
+
+func (l *fakeLoader) ListGroupsByUserId(ctx context.Context, userId int64, opts data.ListOptions) (*data.GroupList, error) {
+	l.mu.Lock()
+	l.userIds = append(l.userIds, userId)
+	l.opts = append(l.opts, opts)
+	l.mu.Unlock()
+
+	if userId == 1 {
+		return &data.GroupList{Groups: []data.Group{{Id: 10, Name: "A"}}}, nil
+	}
+
+	return &data.GroupList{Groups: []data.Group{{Id: 10, Name: "A"}}, NextCursor: "next"}, nil
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func query(t *testing.T, h http.Handler, q string) response {
+	body, err := json.Marshal(map[string]string{"query": q})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body))))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestGroupUsersGroups(t *testing.T) {
+	var loaders []*fakeLoader
+	h := graph.NewHandler(newFakeStore(), func() graph.Loader {
+		l := &fakeLoader{}
+		loaders = append(loaders, l)
+
+		return l
+	})
+
+	resp := query(t, h, `{ group(id: "10") { name users { users { id groups(limit: 1) { groups { id name } nextCursor } } } } }`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"group": {"name": "A", "users": {"users": [
+		{"id": "1", "groups": {"groups": [{"id": "10", "name": "A"}], "nextCursor": ""}},
+		{"id": "2", "groups": {"groups": [{"id": "10", "name": "A"}], "nextCursor": "next"}}
+	]}}}`, string(resp.Data))
+
+	// the groups of every user are looked up through the same loader, so that it can batch them
+	require.Len(t, loaders, 1)
+	sort.Slice(loaders[0].userIds, func(i, j int) bool { return loaders[0].userIds[i] < loaders[0].userIds[j] })
+	assert.Equal(t, []int64{1, 2}, loaders[0].userIds)
+
+	// the arguments are passed on as list options
+	assert.Equal(t, []data.ListOptions{{}, {Limit: 1}, {Limit: 1}}, loaders[0].opts)
+
+	query(t, h, `{ user(id: "1") { groups(cursor: "abc") { groups { id } } } }`)
+	require.Len(t, loaders, 2)
+	assert.Equal(t, []data.ListOptions{{Cursor: "abc"}}, loaders[1].opts)
+}
+
+func TestQueryDepth(t *testing.T) {
+	h := graph.NewHandler(newFakeStore(), func() graph.Loader { return &fakeLoader{} })
+
+	resp := query(t, h, `{ group(id: "10") { users { users { groups { groups { users { users { groups { groups { users { users { id } } } } } } } } } } } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "exceeds max depth 10")
+	assert.Empty(t, resp.Data)
+}
+
+func TestQueryUser(t *testing.T) {
+	store := newFakeStore()
+	h := graph.NewHandler(store, func() graph.Loader { return &fakeLoader{} })
+
+	resp := query(t, h, `{ user(id: "1") { id firstName lastName email version } }`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"user": {"id": "1", "firstName": "Bo", "lastName": "Peep", "email": null, "version": 2}}`, string(resp.Data))
+
+	resp = query(t, h, `{ user(id: "1000") { id } }`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"user": null}`, string(resp.Data))
+
+	resp = query(t, h, `{ user(id: "abc") { id } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "invalid id", resp.Errors[0].Message)
+
+	store.err = &data.Error{Msg: data.ErrConnectionFailed, Kind: data.ErrConnection}
+	resp = query(t, h, `{ users(limit: 1) { nextCursor } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, data.ErrConnectionFailed, resp.Errors[0].Message)
+}
+
+func TestQueryMembership(t *testing.T) {
+	h := graph.NewHandler(newFakeStore(), func() graph.Loader { return &fakeLoader{} })
+
+	resp := query(t, h, `{
+		membership(groupId: "11", userId: "2") { role attributes group { name parentId } user { firstName } }
+		users { users { id } nextCursor }
+	}`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{
+		"membership": {"role": "admin", "attributes": {"title": "lead"}, "group": {"name": "B", "parentId": "10"}, "user": {"firstName": "Jack"}},
+		"users": {"users": [{"id": "1"}], "nextCursor": "next"}
+	}`, string(resp.Data))
+}
@@ -37,6 +37,15 @@ func main() {
 			Destination: &envFilepath,
 			Value: ".env",
 		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Print resources as json, pretty, yaml, table, csv or template",
+			Value: "json",
+		},
+		cli.StringFlag{
+			Name:  "template",
+			Usage: "Go text/template `TEMPLATE` used by --output template",
+		},
 	}
 
 	app.Before = func(context *cli.Context) error {
@@ -53,14 +62,21 @@ func main() {
 
 	migrationCommand := appcli.NewMigrateCommand("migrate", chDataVars)
 	resourcesCommand := appcli.NewResourcesCommand("resources", chDataVars, l)
+	serveCommand := appcli.NewServeCommand("serve", chDataVars, l)
+	serveGRPCCommand := appcli.NewServeGRPCCommand("serve-grpc", chDataVars, l)
+	openAPICommand := appcli.NewOpenAPICommand("openapi")
 
 	app.Commands = []cli.Command{
 		migrationCommand,
 		resourcesCommand,
+		serveCommand,
+		serveGRPCCommand,
+		openAPICommand,
 	}
 
 	err = app.Run(os.Args)
 	if err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(appcli.ExitCode(err))
 	}
 }
@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"github.com/brietsparks/xcrud/data"
+	"github.com/brietsparks/xcrud/rpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"slices"
+	"strings"
+)
+
+// userFromProto converts the writable fields of a user
+func userFromProto(u *pb.User) *data.User {
+	if u == nil {
+		u = &pb.User{}
+	}
+
+	return &data.User{
+		FirstName: u.GetFirstName(),
+		LastName:  u.GetLastName(),
+		Email:     u.Email,
+		Username:  u.Username,
+	}
+}
+
+func userToProto(u *data.User) *pb.User {
+	return &pb.User{
+		Id:        u.Id,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		Username:  u.Username,
+		Version:   u.Version,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+		UpdatedAt: timestamppb.New(u.UpdatedAt),
+	}
+}
+
+// groupFromProto converts the writable fields of a group
+func groupFromProto(g *pb.Group) *data.Group {
+	if g == nil {
+		g = &pb.Group{}
+	}
+
+	return &data.Group{
+		Name:     g.GetName(),
+		ParentId: g.ParentId,
+	}
+}
+
+func groupToProto(g *data.Group) *pb.Group {
+	return &pb.Group{
+		Id:        g.Id,
+		Name:      g.Name,
+		ParentId:  g.ParentId,
+		Version:   g.Version,
+		CreatedAt: timestamppb.New(g.CreatedAt),
+		UpdatedAt: timestamppb.New(g.UpdatedAt),
+	}
+}
+
+// membershipToProto converts a membership, whose attributes fail to convert if they are not JSON values
+func membershipToProto(m *data.Membership) (*pb.Membership, error) {
+	var attributes *structpb.Struct
+
+	if m.Attributes != nil {
+		var err error
+		attributes, err = structpb.NewStruct(m.Attributes)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &pb.Membership{
+		GroupId:    m.GroupId,
+		UserId:     m.UserId,
+		Role:       string(m.Role),
+		Attributes: attributes,
+		LinkedAt:   timestamppb.New(m.LinkedAt),
+	}, nil
+}
+
+// the fields of users and groups that can be updated
+var userFields = data.UpdatableFields[data.User]()
+var groupFields = data.UpdatableFields[data.Group]()
+
+// maskFields converts the paths of an update mask of a message to the names of the fields of the model,
+// such as first_name to FirstName. Paths of fields that are not in updatable are invalid
+func maskFields(mask *fieldmaskpb.FieldMask, m proto.Message, updatable []string) ([]string, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "update_mask is required")
+	}
+
+	if !mask.IsValid(m) {
+		return nil, status.Error(codes.InvalidArgument, "invalid update_mask")
+	}
+
+	fields := make([]string, len(mask.GetPaths()))
+
+	for i, path := range mask.GetPaths() {
+		words := strings.Split(path, "_")
+
+		for j, word := range words {
+			if word == "" {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid update_mask path %q", path)
+			}
+
+			words[j] = strings.ToUpper(word[:1]) + word[1:]
+		}
+
+		fields[i] = strings.Join(words, "")
+
+		if !slices.Contains(updatable, fields[i]) {
+			return nil, status.Errorf(codes.InvalidArgument, "field %q cannot be updated", path)
+		}
+	}
+
+	return fields, nil
+}
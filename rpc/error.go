@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/brietsparks/xcrud/data"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// code returns the status code of an error of the store
+func code(err error) codes.Code {
+	var validationErr *data.ValidationError
+	var versionErr *data.VersionConflictError
+	var membershipsErr *data.MembershipsExistError
+
+	switch {
+	case errors.As(err, &validationErr), errors.Is(err, data.ErrInvalidOptions):
+		return codes.InvalidArgument
+	case errors.As(err, &versionErr):
+		return codes.Aborted
+	case errors.As(err, &membershipsErr):
+		return codes.FailedPrecondition
+	case errors.Is(err, data.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, data.ErrForeignKey):
+		return codes.FailedPrecondition
+	case errors.Is(err, data.ErrConflict):
+		return codes.AlreadyExists
+	case errors.Is(err, data.ErrConnection):
+		return codes.Unavailable
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, data.ErrInterrupted):
+		return codes.Canceled
+	}
+
+	return codes.Internal
+}
+
+// toStatus converts an error of the store to a status error, whose message hides database details.
+// The violations of a validation error are attached as a BadRequest detail
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	st := status.New(code(err), err.Error())
+	var validationErr *data.ValidationError
+
+	if errors.As(err, &validationErr) {
+		detail := &errdetails.BadRequest{}
+
+		for _, v := range validationErr.Violations {
+			detail.FieldViolations = append(detail.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Message,
+			})
+		}
+
+		if withDetails, err := st.WithDetails(detail); err == nil {
+			st = withDetails
+		}
+	}
+
+	return st.Err()
+}
+
+func unaryErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+
+	return resp, toStatus(err)
+}
+
+func streamErrors(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return toStatus(handler(srv, stream))
+}
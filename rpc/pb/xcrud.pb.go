@@ -0,0 +1,1228 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: xcrud.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// DeletePolicy decides what happens to the memberships of a deleted user or group
+type DeletePolicy int32
+
+const (
+	DeletePolicy_DELETE_POLICY_KEEP     DeletePolicy = 0
+	DeletePolicy_DELETE_POLICY_RESTRICT DeletePolicy = 1
+	DeletePolicy_DELETE_POLICY_CASCADE  DeletePolicy = 2
+)
+
+// Enum value maps for DeletePolicy.
+var (
+	DeletePolicy_name = map[int32]string{
+		0: "DELETE_POLICY_KEEP",
+		1: "DELETE_POLICY_RESTRICT",
+		2: "DELETE_POLICY_CASCADE",
+	}
+	DeletePolicy_value = map[string]int32{
+		"DELETE_POLICY_KEEP":     0,
+		"DELETE_POLICY_RESTRICT": 1,
+		"DELETE_POLICY_CASCADE":  2,
+	}
+)
+
+func (x DeletePolicy) Enum() *DeletePolicy {
+	p := new(DeletePolicy)
+	*p = x
+	return p
+}
+
+func (x DeletePolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeletePolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_xcrud_proto_enumTypes[0].Descriptor()
+}
+
+func (DeletePolicy) Type() protoreflect.EnumType {
+	return &file_xcrud_proto_enumTypes[0]
+}
+
+func (x DeletePolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeletePolicy.Descriptor instead.
+func (DeletePolicy) EnumDescriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{0}
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	FirstName     string                 `protobuf:"bytes,2,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName      string                 `protobuf:"bytes,3,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	Email         *string                `protobuf:"bytes,4,opt,name=email,proto3,oneof" json:"email,omitempty"`
+	Username      *string                `protobuf:"bytes,5,opt,name=username,proto3,oneof" json:"username,omitempty"`
+	Version       int64                  `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_xcrud_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetFirstName() string {
+	if x != nil {
+		return x.FirstName
+	}
+	return ""
+}
+
+func (x *User) GetLastName() string {
+	if x != nil {
+		return x.LastName
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil && x.Email != nil {
+		return *x.Email
+	}
+	return ""
+}
+
+func (x *User) GetUsername() string {
+	if x != nil && x.Username != nil {
+		return *x.Username
+	}
+	return ""
+}
+
+func (x *User) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *User) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type Group struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ParentId      *int64                 `protobuf:"varint,3,opt,name=parent_id,json=parentId,proto3,oneof" json:"parent_id,omitempty"`
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Group) Reset() {
+	*x = Group{}
+	mi := &file_xcrud_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Group) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Group) ProtoMessage() {}
+
+func (x *Group) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Group.ProtoReflect.Descriptor instead.
+func (*Group) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Group) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Group) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Group) GetParentId() int64 {
+	if x != nil && x.ParentId != nil {
+		return *x.ParentId
+	}
+	return 0
+}
+
+func (x *Group) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Group) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Group) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type Membership struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Role          string                 `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	Attributes    *structpb.Struct       `protobuf:"bytes,4,opt,name=attributes,proto3" json:"attributes,omitempty"`
+	LinkedAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=linked_at,json=linkedAt,proto3" json:"linked_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Membership) Reset() {
+	*x = Membership{}
+	mi := &file_xcrud_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Membership) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Membership) ProtoMessage() {}
+
+func (x *Membership) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Membership.ProtoReflect.Descriptor instead.
+func (*Membership) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Membership) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *Membership) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Membership) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Membership) GetAttributes() *structpb.Struct {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *Membership) GetLinkedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LinkedAt
+	}
+	return nil
+}
+
+type CreateUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateUserRequest) Reset() {
+	*x = CreateUserRequest{}
+	mi := &file_xcrud_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateUserRequest) ProtoMessage() {}
+
+func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateUserRequest.ProtoReflect.Descriptor instead.
+func (*CreateUserRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateUserRequest) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_xcrud_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetUserRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type UpdateUserRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	User  *User                  `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	// the fields of user to update, which is required
+	UpdateMask *fieldmaskpb.FieldMask `protobuf:"bytes,3,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	// makes the update fail with ABORTED unless the user is at this version
+	Version       *int64 `protobuf:"varint,4,opt,name=version,proto3,oneof" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUserRequest) Reset() {
+	*x = UpdateUserRequest{}
+	mi := &file_xcrud_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserRequest) ProtoMessage() {}
+
+func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserRequest.ProtoReflect.Descriptor instead.
+func (*UpdateUserRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateUserRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateUserRequest) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *UpdateUserRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateUserRequest) GetVersion() int64 {
+	if x != nil && x.Version != nil {
+		return *x.Version
+	}
+	return 0
+}
+
+type DeleteUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Policy        DeletePolicy           `protobuf:"varint,2,opt,name=policy,proto3,enum=xcrud.v1.DeletePolicy" json:"policy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserRequest) Reset() {
+	*x = DeleteUserRequest{}
+	mi := &file_xcrud_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserRequest) ProtoMessage() {}
+
+func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
+func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteUserRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteUserRequest) GetPolicy() DeletePolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return DeletePolicy_DELETE_POLICY_KEEP
+}
+
+type CreateGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         *Group                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateGroupRequest) Reset() {
+	*x = CreateGroupRequest{}
+	mi := &file_xcrud_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGroupRequest) ProtoMessage() {}
+
+func (x *CreateGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGroupRequest.ProtoReflect.Descriptor instead.
+func (*CreateGroupRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateGroupRequest) GetGroup() *Group {
+	if x != nil {
+		return x.Group
+	}
+	return nil
+}
+
+type GetGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGroupRequest) Reset() {
+	*x = GetGroupRequest{}
+	mi := &file_xcrud_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGroupRequest) ProtoMessage() {}
+
+func (x *GetGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGroupRequest.ProtoReflect.Descriptor instead.
+func (*GetGroupRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetGroupRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type UpdateGroupRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Group *Group                 `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+	// the fields of group to update, which is required
+	UpdateMask *fieldmaskpb.FieldMask `protobuf:"bytes,3,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	// makes the update fail with ABORTED unless the group is at this version
+	Version       *int64 `protobuf:"varint,4,opt,name=version,proto3,oneof" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateGroupRequest) Reset() {
+	*x = UpdateGroupRequest{}
+	mi := &file_xcrud_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateGroupRequest) ProtoMessage() {}
+
+func (x *UpdateGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateGroupRequest.ProtoReflect.Descriptor instead.
+func (*UpdateGroupRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateGroupRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateGroupRequest) GetGroup() *Group {
+	if x != nil {
+		return x.Group
+	}
+	return nil
+}
+
+func (x *UpdateGroupRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateGroupRequest) GetVersion() int64 {
+	if x != nil && x.Version != nil {
+		return *x.Version
+	}
+	return 0
+}
+
+type DeleteGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Policy        DeletePolicy           `protobuf:"varint,2,opt,name=policy,proto3,enum=xcrud.v1.DeletePolicy" json:"policy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteGroupRequest) Reset() {
+	*x = DeleteGroupRequest{}
+	mi := &file_xcrud_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteGroupRequest) ProtoMessage() {}
+
+func (x *DeleteGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteGroupRequest.ProtoReflect.Descriptor instead.
+func (*DeleteGroupRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteGroupRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteGroupRequest) GetPolicy() DeletePolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return DeletePolicy_DELETE_POLICY_KEEP
+}
+
+type LinkGroupToUserRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	GroupId int64                  `protobuf:"varint,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	UserId  int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// owner, admin or member, which is the default
+	Role          string `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkGroupToUserRequest) Reset() {
+	*x = LinkGroupToUserRequest{}
+	mi := &file_xcrud_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkGroupToUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkGroupToUserRequest) ProtoMessage() {}
+
+func (x *LinkGroupToUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkGroupToUserRequest.ProtoReflect.Descriptor instead.
+func (*LinkGroupToUserRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *LinkGroupToUserRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *LinkGroupToUserRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *LinkGroupToUserRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+type GetMembershipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMembershipRequest) Reset() {
+	*x = GetMembershipRequest{}
+	mi := &file_xcrud_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMembershipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMembershipRequest) ProtoMessage() {}
+
+func (x *GetMembershipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMembershipRequest.ProtoReflect.Descriptor instead.
+func (*GetMembershipRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetMembershipRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *GetMembershipRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type UnlinkGroupFromUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnlinkGroupFromUserRequest) Reset() {
+	*x = UnlinkGroupFromUserRequest{}
+	mi := &file_xcrud_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnlinkGroupFromUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnlinkGroupFromUserRequest) ProtoMessage() {}
+
+func (x *UnlinkGroupFromUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnlinkGroupFromUserRequest.ProtoReflect.Descriptor instead.
+func (*UnlinkGroupFromUserRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UnlinkGroupFromUserRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+func (x *UnlinkGroupFromUserRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type GetUsersByGroupIdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GroupId       int64                  `protobuf:"varint,1,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUsersByGroupIdRequest) Reset() {
+	*x = GetUsersByGroupIdRequest{}
+	mi := &file_xcrud_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUsersByGroupIdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUsersByGroupIdRequest) ProtoMessage() {}
+
+func (x *GetUsersByGroupIdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUsersByGroupIdRequest.ProtoReflect.Descriptor instead.
+func (*GetUsersByGroupIdRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetUsersByGroupIdRequest) GetGroupId() int64 {
+	if x != nil {
+		return x.GroupId
+	}
+	return 0
+}
+
+type GetGroupsByUserIdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGroupsByUserIdRequest) Reset() {
+	*x = GetGroupsByUserIdRequest{}
+	mi := &file_xcrud_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGroupsByUserIdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGroupsByUserIdRequest) ProtoMessage() {}
+
+func (x *GetGroupsByUserIdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xcrud_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGroupsByUserIdRequest.ProtoReflect.Descriptor instead.
+func (*GetGroupsByUserIdRequest) Descriptor() ([]byte, []int) {
+	return file_xcrud_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetGroupsByUserIdRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+var File_xcrud_proto protoreflect.FileDescriptor
+
+const file_xcrud_proto_rawDesc = "" +
+	"\n" +
+	"\vxcrud.proto\x12\bxcrud.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a google/protobuf/field_mask.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb5\x02\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1d\n" +
+	"\n" +
+	"first_name\x18\x02 \x01(\tR\tfirstName\x12\x1b\n" +
+	"\tlast_name\x18\x03 \x01(\tR\blastName\x12\x19\n" +
+	"\x05email\x18\x04 \x01(\tH\x00R\x05email\x88\x01\x01\x12\x1f\n" +
+	"\busername\x18\x05 \x01(\tH\x01R\busername\x88\x01\x01\x12\x18\n" +
+	"\aversion\x18\x06 \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\b\n" +
+	"\x06_emailB\v\n" +
+	"\t_username\"\xeb\x01\n" +
+	"\x05Group\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\tparent_id\x18\x03 \x01(\x03H\x00R\bparentId\x88\x01\x01\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\f\n" +
+	"\n" +
+	"_parent_id\"\xc6\x01\n" +
+	"\n" +
+	"Membership\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\x03R\agroupId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x127\n" +
+	"\n" +
+	"attributes\x18\x04 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"attributes\x127\n" +
+	"\tlinked_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\blinkedAt\"7\n" +
+	"\x11CreateUserRequest\x12\"\n" +
+	"\x04user\x18\x01 \x01(\v2\x0e.xcrud.v1.UserR\x04user\" \n" +
+	"\x0eGetUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xaf\x01\n" +
+	"\x11UpdateUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\"\n" +
+	"\x04user\x18\x02 \x01(\v2\x0e.xcrud.v1.UserR\x04user\x12;\n" +
+	"\vupdate_mask\x18\x03 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12\x1d\n" +
+	"\aversion\x18\x04 \x01(\x03H\x00R\aversion\x88\x01\x01B\n" +
+	"\n" +
+	"\b_version\"S\n" +
+	"\x11DeleteUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12.\n" +
+	"\x06policy\x18\x02 \x01(\x0e2\x16.xcrud.v1.DeletePolicyR\x06policy\";\n" +
+	"\x12CreateGroupRequest\x12%\n" +
+	"\x05group\x18\x01 \x01(\v2\x0f.xcrud.v1.GroupR\x05group\"!\n" +
+	"\x0fGetGroupRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xb3\x01\n" +
+	"\x12UpdateGroupRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12%\n" +
+	"\x05group\x18\x02 \x01(\v2\x0f.xcrud.v1.GroupR\x05group\x12;\n" +
+	"\vupdate_mask\x18\x03 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12\x1d\n" +
+	"\aversion\x18\x04 \x01(\x03H\x00R\aversion\x88\x01\x01B\n" +
+	"\n" +
+	"\b_version\"T\n" +
+	"\x12DeleteGroupRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12.\n" +
+	"\x06policy\x18\x02 \x01(\x0e2\x16.xcrud.v1.DeletePolicyR\x06policy\"`\n" +
+	"\x16LinkGroupToUserRequest\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\x03R\agroupId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\"J\n" +
+	"\x14GetMembershipRequest\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\x03R\agroupId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\"P\n" +
+	"\x1aUnlinkGroupFromUserRequest\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\x03R\agroupId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\"5\n" +
+	"\x18GetUsersByGroupIdRequest\x12\x19\n" +
+	"\bgroup_id\x18\x01 \x01(\x03R\agroupId\"3\n" +
+	"\x18GetGroupsByUserIdRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId*]\n" +
+	"\fDeletePolicy\x12\x16\n" +
+	"\x12DELETE_POLICY_KEEP\x10\x00\x12\x1a\n" +
+	"\x16DELETE_POLICY_RESTRICT\x10\x01\x12\x19\n" +
+	"\x15DELETE_POLICY_CASCADE\x10\x022\xec\x06\n" +
+	"\x05Store\x129\n" +
+	"\n" +
+	"CreateUser\x12\x1b.xcrud.v1.CreateUserRequest\x1a\x0e.xcrud.v1.User\x123\n" +
+	"\aGetUser\x12\x18.xcrud.v1.GetUserRequest\x1a\x0e.xcrud.v1.User\x129\n" +
+	"\n" +
+	"UpdateUser\x12\x1b.xcrud.v1.UpdateUserRequest\x1a\x0e.xcrud.v1.User\x12A\n" +
+	"\n" +
+	"DeleteUser\x12\x1b.xcrud.v1.DeleteUserRequest\x1a\x16.google.protobuf.Empty\x12<\n" +
+	"\vCreateGroup\x12\x1c.xcrud.v1.CreateGroupRequest\x1a\x0f.xcrud.v1.Group\x126\n" +
+	"\bGetGroup\x12\x19.xcrud.v1.GetGroupRequest\x1a\x0f.xcrud.v1.Group\x12<\n" +
+	"\vUpdateGroup\x12\x1c.xcrud.v1.UpdateGroupRequest\x1a\x0f.xcrud.v1.Group\x12C\n" +
+	"\vDeleteGroup\x12\x1c.xcrud.v1.DeleteGroupRequest\x1a\x16.google.protobuf.Empty\x12I\n" +
+	"\x0fLinkGroupToUser\x12 .xcrud.v1.LinkGroupToUserRequest\x1a\x14.xcrud.v1.Membership\x12E\n" +
+	"\rGetMembership\x12\x1e.xcrud.v1.GetMembershipRequest\x1a\x14.xcrud.v1.Membership\x12S\n" +
+	"\x13UnlinkGroupFromUser\x12$.xcrud.v1.UnlinkGroupFromUserRequest\x1a\x16.google.protobuf.Empty\x12I\n" +
+	"\x11GetUsersByGroupId\x12\".xcrud.v1.GetUsersByGroupIdRequest\x1a\x0e.xcrud.v1.User0\x01\x12J\n" +
+	"\x11GetGroupsByUserId\x12\".xcrud.v1.GetGroupsByUserIdRequest\x1a\x0f.xcrud.v1.Group0\x01B%Z#github.com/brietsparks/xcrud/rpc/pbb\x06proto3"
+
+var (
+	file_xcrud_proto_rawDescOnce sync.Once
+	file_xcrud_proto_rawDescData []byte
+)
+
+func file_xcrud_proto_rawDescGZIP() []byte {
+	file_xcrud_proto_rawDescOnce.Do(func() {
+		file_xcrud_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_xcrud_proto_rawDesc), len(file_xcrud_proto_rawDesc)))
+	})
+	return file_xcrud_proto_rawDescData
+}
+
+var file_xcrud_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_xcrud_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_xcrud_proto_goTypes = []any{
+	(DeletePolicy)(0),                  // 0: xcrud.v1.DeletePolicy
+	(*User)(nil),                       // 1: xcrud.v1.User
+	(*Group)(nil),                      // 2: xcrud.v1.Group
+	(*Membership)(nil),                 // 3: xcrud.v1.Membership
+	(*CreateUserRequest)(nil),          // 4: xcrud.v1.CreateUserRequest
+	(*GetUserRequest)(nil),             // 5: xcrud.v1.GetUserRequest
+	(*UpdateUserRequest)(nil),          // 6: xcrud.v1.UpdateUserRequest
+	(*DeleteUserRequest)(nil),          // 7: xcrud.v1.DeleteUserRequest
+	(*CreateGroupRequest)(nil),         // 8: xcrud.v1.CreateGroupRequest
+	(*GetGroupRequest)(nil),            // 9: xcrud.v1.GetGroupRequest
+	(*UpdateGroupRequest)(nil),         // 10: xcrud.v1.UpdateGroupRequest
+	(*DeleteGroupRequest)(nil),         // 11: xcrud.v1.DeleteGroupRequest
+	(*LinkGroupToUserRequest)(nil),     // 12: xcrud.v1.LinkGroupToUserRequest
+	(*GetMembershipRequest)(nil),       // 13: xcrud.v1.GetMembershipRequest
+	(*UnlinkGroupFromUserRequest)(nil), // 14: xcrud.v1.UnlinkGroupFromUserRequest
+	(*GetUsersByGroupIdRequest)(nil),   // 15: xcrud.v1.GetUsersByGroupIdRequest
+	(*GetGroupsByUserIdRequest)(nil),   // 16: xcrud.v1.GetGroupsByUserIdRequest
+	(*timestamppb.Timestamp)(nil),      // 17: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),            // 18: google.protobuf.Struct
+	(*fieldmaskpb.FieldMask)(nil),      // 19: google.protobuf.FieldMask
+	(*emptypb.Empty)(nil),              // 20: google.protobuf.Empty
+}
+var file_xcrud_proto_depIdxs = []int32{
+	17, // 0: xcrud.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	17, // 1: xcrud.v1.User.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 2: xcrud.v1.Group.created_at:type_name -> google.protobuf.Timestamp
+	17, // 3: xcrud.v1.Group.updated_at:type_name -> google.protobuf.Timestamp
+	18, // 4: xcrud.v1.Membership.attributes:type_name -> google.protobuf.Struct
+	17, // 5: xcrud.v1.Membership.linked_at:type_name -> google.protobuf.Timestamp
+	1,  // 6: xcrud.v1.CreateUserRequest.user:type_name -> xcrud.v1.User
+	1,  // 7: xcrud.v1.UpdateUserRequest.user:type_name -> xcrud.v1.User
+	19, // 8: xcrud.v1.UpdateUserRequest.update_mask:type_name -> google.protobuf.FieldMask
+	0,  // 9: xcrud.v1.DeleteUserRequest.policy:type_name -> xcrud.v1.DeletePolicy
+	2,  // 10: xcrud.v1.CreateGroupRequest.group:type_name -> xcrud.v1.Group
+	2,  // 11: xcrud.v1.UpdateGroupRequest.group:type_name -> xcrud.v1.Group
+	19, // 12: xcrud.v1.UpdateGroupRequest.update_mask:type_name -> google.protobuf.FieldMask
+	0,  // 13: xcrud.v1.DeleteGroupRequest.policy:type_name -> xcrud.v1.DeletePolicy
+	4,  // 14: xcrud.v1.Store.CreateUser:input_type -> xcrud.v1.CreateUserRequest
+	5,  // 15: xcrud.v1.Store.GetUser:input_type -> xcrud.v1.GetUserRequest
+	6,  // 16: xcrud.v1.Store.UpdateUser:input_type -> xcrud.v1.UpdateUserRequest
+	7,  // 17: xcrud.v1.Store.DeleteUser:input_type -> xcrud.v1.DeleteUserRequest
+	8,  // 18: xcrud.v1.Store.CreateGroup:input_type -> xcrud.v1.CreateGroupRequest
+	9,  // 19: xcrud.v1.Store.GetGroup:input_type -> xcrud.v1.GetGroupRequest
+	10, // 20: xcrud.v1.Store.UpdateGroup:input_type -> xcrud.v1.UpdateGroupRequest
+	11, // 21: xcrud.v1.Store.DeleteGroup:input_type -> xcrud.v1.DeleteGroupRequest
+	12, // 22: xcrud.v1.Store.LinkGroupToUser:input_type -> xcrud.v1.LinkGroupToUserRequest
+	13, // 23: xcrud.v1.Store.GetMembership:input_type -> xcrud.v1.GetMembershipRequest
+	14, // 24: xcrud.v1.Store.UnlinkGroupFromUser:input_type -> xcrud.v1.UnlinkGroupFromUserRequest
+	15, // 25: xcrud.v1.Store.GetUsersByGroupId:input_type -> xcrud.v1.GetUsersByGroupIdRequest
+	16, // 26: xcrud.v1.Store.GetGroupsByUserId:input_type -> xcrud.v1.GetGroupsByUserIdRequest
+	1,  // 27: xcrud.v1.Store.CreateUser:output_type -> xcrud.v1.User
+	1,  // 28: xcrud.v1.Store.GetUser:output_type -> xcrud.v1.User
+	1,  // 29: xcrud.v1.Store.UpdateUser:output_type -> xcrud.v1.User
+	20, // 30: xcrud.v1.Store.DeleteUser:output_type -> google.protobuf.Empty
+	2,  // 31: xcrud.v1.Store.CreateGroup:output_type -> xcrud.v1.Group
+	2,  // 32: xcrud.v1.Store.GetGroup:output_type -> xcrud.v1.Group
+	2,  // 33: xcrud.v1.Store.UpdateGroup:output_type -> xcrud.v1.Group
+	20, // 34: xcrud.v1.Store.DeleteGroup:output_type -> google.protobuf.Empty
+	3,  // 35: xcrud.v1.Store.LinkGroupToUser:output_type -> xcrud.v1.Membership
+	3,  // 36: xcrud.v1.Store.GetMembership:output_type -> xcrud.v1.Membership
+	20, // 37: xcrud.v1.Store.UnlinkGroupFromUser:output_type -> google.protobuf.Empty
+	1,  // 38: xcrud.v1.Store.GetUsersByGroupId:output_type -> xcrud.v1.User
+	2,  // 39: xcrud.v1.Store.GetGroupsByUserId:output_type -> xcrud.v1.Group
+	27, // [27:40] is the sub-list for method output_type
+	14, // [14:27] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_xcrud_proto_init() }
+func file_xcrud_proto_init() {
+	if File_xcrud_proto != nil {
+		return
+	}
+	file_xcrud_proto_msgTypes[0].OneofWrappers = []any{}
+	file_xcrud_proto_msgTypes[1].OneofWrappers = []any{}
+	file_xcrud_proto_msgTypes[5].OneofWrappers = []any{}
+	file_xcrud_proto_msgTypes[9].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_xcrud_proto_rawDesc), len(file_xcrud_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_xcrud_proto_goTypes,
+		DependencyIndexes: file_xcrud_proto_depIdxs,
+		EnumInfos:         file_xcrud_proto_enumTypes,
+		MessageInfos:      file_xcrud_proto_msgTypes,
+	}.Build()
+	File_xcrud_proto = out.File
+	file_xcrud_proto_goTypes = nil
+	file_xcrud_proto_depIdxs = nil
+}
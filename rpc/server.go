@@ -0,0 +1,269 @@
+package rpc
+
+//go:generate protoc -I pb --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative pb/xcrud.proto
+
+import (
+	"context"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/brietsparks/xcrud/rpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Store is the part of data.Store that the server exposes
+type Store interface {
+	CreateUserContext(ctx context.Context, u *data.User) (*data.User, error)
+	GetUserByIdContext(ctx context.Context, id int64) (*data.User, error)
+	UpdateUserContext(ctx context.Context, id int64, u *data.User, fields ...string) error
+	UpdateUserIfVersionContext(ctx context.Context, id int64, version int64, u *data.User, fields ...string) error
+	DeleteUserContext(ctx context.Context, id int64, policy ...data.DeletePolicy) error
+
+	CreateGroupContext(ctx context.Context, g *data.Group) (*data.Group, error)
+	GetGroupByIdContext(ctx context.Context, id int64) (*data.Group, error)
+	UpdateGroupContext(ctx context.Context, id int64, g *data.Group, fields ...string) error
+	UpdateGroupIfVersionContext(ctx context.Context, id int64, version int64, g *data.Group, fields ...string) error
+	DeleteGroupContext(ctx context.Context, id int64, policy ...data.DeletePolicy) error
+
+	LinkGroupToUserContext(ctx context.Context, groupId int64, userId int64, role ...data.Role) error
+	GetMembershipContext(ctx context.Context, groupId int64, userId int64) (*data.Membership, error)
+	UnlinkGroupFromUserContext(ctx context.Context, groupId int64, userId int64) error
+
+	ListUsersByGroupIdContext(ctx context.Context, groupId int64, opts data.ListOptions) (*data.UserList, error)
+	ListGroupsByUserIdContext(ctx context.Context, userId int64, opts data.ListOptions) (*data.GroupList, error)
+}
+
+// the number of users or groups that a stream reads from the store at a time
+const streamPageSize = 100
+
+// Server serves a Store as the gRPC service xcrud.v1.Store
+type Server struct {
+	pb.UnimplementedStoreServer
+	store Store
+}
+
+// NewServer creates a Server that reads and writes resources through store
+func NewServer(store Store) *Server {
+	return &Server{store: store}
+}
+
+// Register creates a gRPC server with the Store service of s, which converts the errors of the store to status errors
+func (s *Server) Register(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(unaryErrors),
+		grpc.ChainStreamInterceptor(streamErrors),
+	)
+
+	server := grpc.NewServer(opts...)
+	pb.RegisterStoreServer(server, s)
+
+	return server
+}
+
+func (s *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	u, err := s.store.CreateUserContext(ctx, userFromProto(req.GetUser()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return userToProto(u), nil
+}
+
+func (s *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	u, err := s.store.GetUserByIdContext(ctx, req.GetId())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if u == nil {
+		return nil, data.ErrNotFound
+	}
+
+	return userToProto(u), nil
+}
+
+// UpdateUser updates the fields of the update mask, if the user is at the version of the request when it has one
+func (s *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	fields, err := maskFields(req.GetUpdateMask(), &pb.User{}, userFields)
+
+	if err != nil {
+		return nil, err
+	}
+
+	u := userFromProto(req.GetUser())
+
+	if req.Version != nil {
+		err = s.store.UpdateUserIfVersionContext(ctx, req.GetId(), req.GetVersion(), u, fields...)
+	} else {
+		err = s.store.UpdateUserContext(ctx, req.GetId(), u, fields...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, &pb.GetUserRequest{Id: req.GetId()})
+}
+
+func (s *Server) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	policy, err := deletePolicy(req.GetPolicy())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, s.store.DeleteUserContext(ctx, req.GetId(), policy)
+}
+
+func (s *Server) CreateGroup(ctx context.Context, req *pb.CreateGroupRequest) (*pb.Group, error) {
+	g, err := s.store.CreateGroupContext(ctx, groupFromProto(req.GetGroup()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return groupToProto(g), nil
+}
+
+func (s *Server) GetGroup(ctx context.Context, req *pb.GetGroupRequest) (*pb.Group, error) {
+	g, err := s.store.GetGroupByIdContext(ctx, req.GetId())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if g == nil {
+		return nil, data.ErrNotFound
+	}
+
+	return groupToProto(g), nil
+}
+
+// UpdateGroup updates the fields of the update mask, if the group is at the version of the request when it has one
+func (s *Server) UpdateGroup(ctx context.Context, req *pb.UpdateGroupRequest) (*pb.Group, error) {
+	fields, err := maskFields(req.GetUpdateMask(), &pb.Group{}, groupFields)
+
+	if err != nil {
+		return nil, err
+	}
+
+	g := groupFromProto(req.GetGroup())
+
+	if req.Version != nil {
+		err = s.store.UpdateGroupIfVersionContext(ctx, req.GetId(), req.GetVersion(), g, fields...)
+	} else {
+		err = s.store.UpdateGroupContext(ctx, req.GetId(), g, fields...)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetGroup(ctx, &pb.GetGroupRequest{Id: req.GetId()})
+}
+
+func (s *Server) DeleteGroup(ctx context.Context, req *pb.DeleteGroupRequest) (*emptypb.Empty, error) {
+	policy, err := deletePolicy(req.GetPolicy())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, s.store.DeleteGroupContext(ctx, req.GetId(), policy)
+}
+
+func (s *Server) LinkGroupToUser(ctx context.Context, req *pb.LinkGroupToUserRequest) (*pb.Membership, error) {
+	err := s.store.LinkGroupToUserContext(ctx, req.GetGroupId(), req.GetUserId(), data.Role(req.GetRole()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetMembership(ctx, &pb.GetMembershipRequest{GroupId: req.GetGroupId(), UserId: req.GetUserId()})
+}
+
+func (s *Server) GetMembership(ctx context.Context, req *pb.GetMembershipRequest) (*pb.Membership, error) {
+	m, err := s.store.GetMembershipContext(ctx, req.GetGroupId(), req.GetUserId())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m == nil {
+		return nil, data.ErrNotFound
+	}
+
+	return membershipToProto(m)
+}
+
+func (s *Server) UnlinkGroupFromUser(ctx context.Context, req *pb.UnlinkGroupFromUserRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, s.store.UnlinkGroupFromUserContext(ctx, req.GetGroupId(), req.GetUserId())
+}
+
+// GetUsersByGroupId sends the users of a group while reading further pages of them,
+// so that large groups are not held in memory at once
+func (s *Server) GetUsersByGroupId(req *pb.GetUsersByGroupIdRequest, stream pb.Store_GetUsersByGroupIdServer) error {
+	opts := data.ListOptions{Limit: streamPageSize}
+
+	for {
+		page, err := s.store.ListUsersByGroupIdContext(stream.Context(), req.GetGroupId(), opts)
+
+		if err != nil {
+			return err
+		}
+
+		for i := range page.Users {
+			if err := stream.Send(userToProto(&page.Users[i])); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// GetGroupsByUserId sends the groups of a user while reading further pages of them
+func (s *Server) GetGroupsByUserId(req *pb.GetGroupsByUserIdRequest, stream pb.Store_GetGroupsByUserIdServer) error {
+	opts := data.ListOptions{Limit: streamPageSize}
+
+	for {
+		page, err := s.store.ListGroupsByUserIdContext(stream.Context(), req.GetUserId(), opts)
+
+		if err != nil {
+			return err
+		}
+
+		for i := range page.Groups {
+			if err := stream.Send(groupToProto(&page.Groups[i])); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// deletePolicy converts a delete policy of a request
+func deletePolicy(policy pb.DeletePolicy) (data.DeletePolicy, error) {
+	switch policy {
+	case pb.DeletePolicy_DELETE_POLICY_KEEP:
+		return data.DeleteKeep, nil
+	case pb.DeletePolicy_DELETE_POLICY_RESTRICT:
+		return data.DeleteRestrict, nil
+	case pb.DeletePolicy_DELETE_POLICY_CASCADE:
+		return data.DeleteCascade, nil
+	}
+
+	return 0, status.Error(codes.InvalidArgument, "invalid policy")
+}
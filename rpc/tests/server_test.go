@@ -0,0 +1,312 @@
+package tests
+
+import (
+	"context"
+	"github.com/brietsparks/xcrud/data"
+	"github.com/brietsparks/xcrud/rpc"
+	"github.com/brietsparks/xcrud/rpc/pb"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"io"
+	"net"
+	"testing"
+)
+
+// fakeStore records the calls of the server and returns canned results.
+// Methods that a test does not expect panic through the nil embedded Store
+type fakeStore struct {
+	rpc.Store
+
+	users       map[int64]*data.User
+	memberships map[[2]int64]*data.Membership
+	err         error
+
+	fields  []string
+	version int64
+	policy  data.DeletePolicy
+	cursors []string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:       map[int64]*data.User{1: {Id: 1, FirstName: "Bo", LastName: "Peep", Version: 3}},
+		memberships: map[[2]int64]*data.Membership{},
+	}
+}
+
+func (f *fakeStore) CreateUserContext(ctx context.Context, u *data.User) (*data.User, error) {
+	if u.FirstName == "" {
+		return nil, &data.ValidationError{Violations: []data.Violation{
+			{Field: "firstName", Rule: "required", Message: "firstName is required"},
+		}}
+	}
+
+	u.Id = 10
+	u.Version = 1
+
+	return u, nil
+}
+
+func (f *fakeStore) GetUserByIdContext(ctx context.Context, id int64) (*data.User, error) {
+	return f.users[id], f.err
+}
+
+func (f *fakeStore) UpdateUserContext(ctx context.Context, id int64, u *data.User, fields ...string) error {
+	f.fields = fields
+	f.users[id].FirstName = u.FirstName
+
+	return nil
+}
+
+func (f *fakeStore) UpdateUserIfVersionContext(ctx context.Context, id int64, version int64, u *data.User, fields ...string) error {
+	f.version = version
+
+	if version != f.users[id].Version {
+		return &data.VersionConflictError{Expected: version, Actual: f.users[id].Version}
+	}
+
+	return f.UpdateUserContext(ctx, id, u, fields...)
+}
+
+func (f *fakeStore) DeleteUserContext(ctx context.Context, id int64, policy ...data.DeletePolicy) error {
+	f.policy = policy[0]
+
+	return f.err
+}
+
+func (f *fakeStore) LinkGroupToUserContext(ctx context.Context, groupId int64, userId int64, role ...data.Role) error {
+	if _, ok := f.memberships[[2]int64{groupId, userId}]; ok {
+		return &data.Error{Msg: data.ErrGroupUserAlreadyLinked, Kind: data.ErrConflict}
+	}
+
+	f.memberships[[2]int64{groupId, userId}] = &data.Membership{
+		GroupId:    groupId,
+		UserId:     userId,
+		Role:       role[0],
+		Attributes: data.Attributes{"title": "lead"},
+	}
+
+	return nil
+}
+
+func (f *fakeStore) GetMembershipContext(ctx context.Context, groupId int64, userId int64) (*data.Membership, error) {
+	return f.memberships[[2]int64{groupId, userId}], nil
+}
+
+// ListUsersByGroupIdContext returns two pages of users, or fails on the second page when err is set
+func (f *fakeStore) ListUsersByGroupIdContext(ctx context.Context, groupId int64, opts data.ListOptions) (*data.UserList, error) {
+	f.cursors = append(f.cursors, opts.Cursor)
+
+	if opts.Cursor == "" {
+		return &data.UserList{Users: []data.User{{Id: 1}, {Id: 2}}, NextCursor: "next"}, nil
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &data.UserList{Users: []data.User{{Id: 3}}}, nil
+}
+
+// dial serves store in process and returns a client of it
+func dial(t *testing.T, store rpc.Store) pb.StoreClient {
+	lis := bufconn.Listen(1024 * 1024)
+	server := rpc.NewServer(store).Register()
+
+	go func() {
+		_ = server.Serve(lis)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		server.Stop()
+	})
+
+	return pb.NewStoreClient(conn)
+}
+
+func TestCreateUser(t *testing.T) {
+	client := dial(t, newFakeStore())
+	ctx := context.Background()
+
+	u, err := client.CreateUser(ctx, &pb.CreateUserRequest{User: &pb.User{FirstName: "Jo", LastName: "Jackson"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), u.Id)
+	assert.Equal(t, "Jo", u.FirstName)
+
+	_, err = client.CreateUser(ctx, &pb.CreateUserRequest{User: &pb.User{LastName: "Jackson"}})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "invalid resource: firstName is required", st.Message())
+
+	require.Len(t, st.Details(), 1)
+	badRequest := st.Details()[0].(*errdetails.BadRequest)
+	assert.Equal(t, "firstName", badRequest.FieldViolations[0].Field)
+	assert.Equal(t, "firstName is required", badRequest.FieldViolations[0].Description)
+}
+
+func TestGetUser(t *testing.T) {
+	store := newFakeStore()
+	client := dial(t, store)
+	ctx := context.Background()
+
+	u, err := client.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Peep", u.LastName)
+	assert.Equal(t, int64(3), u.Version)
+
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{Id: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	store.err = &data.Error{Msg: data.ErrConnectionFailed, Kind: data.ErrConnection}
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	store.err = data.NewError(&pq.Error{Code: "57014"})
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	store.err = &data.Error{Msg: data.ErrUnknown}
+	_, err = client.GetUser(ctx, &pb.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "unspecified database error", status.Convert(err).Message())
+}
+
+func TestUpdateUser(t *testing.T) {
+	store := newFakeStore()
+	client := dial(t, store)
+	ctx := context.Background()
+
+	u, err := client.UpdateUser(ctx, &pb.UpdateUserRequest{
+		Id:         1,
+		User:       &pb.User{FirstName: "Jo", LastName: "ignored"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"first_name"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"FirstName"}, store.fields)
+	assert.Equal(t, "Jo", u.FirstName)
+
+	version := int64(2)
+	_, err = client.UpdateUser(ctx, &pb.UpdateUserRequest{
+		Id:         1,
+		User:       &pb.User{FirstName: "Bo"},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"first_name"}},
+		Version:    &version,
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.Equal(t, int64(2), store.version)
+
+	_, err = client.UpdateUser(ctx, &pb.UpdateUserRequest{Id: 1, User: &pb.User{FirstName: "Bo"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.UpdateUser(ctx, &pb.UpdateUserRequest{
+		Id:         1,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"nickname"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	// fields that the store does not update, and malformed paths, are rejected before reaching it
+	store.fields = nil
+
+	for _, path := range []string{"id", "version", "created_at", "a__b", "first_name_", "_"} {
+		_, err = client.UpdateUser(ctx, &pb.UpdateUserRequest{
+			Id:         1,
+			User:       &pb.User{FirstName: "Bo"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{path}},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), path)
+	}
+
+	assert.Nil(t, store.fields)
+
+	_, err = client.UpdateGroup(ctx, &pb.UpdateGroupRequest{
+		Id:         2,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"parent_id"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestDeleteUser(t *testing.T) {
+	store := newFakeStore()
+	client := dial(t, store)
+	ctx := context.Background()
+
+	_, err := client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1, Policy: pb.DeletePolicy_DELETE_POLICY_CASCADE})
+	require.NoError(t, err)
+	assert.Equal(t, data.DeleteCascade, store.policy)
+
+	_, err = client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1, Policy: 10})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	store.err = &data.MembershipsExistError{Memberships: []data.Membership{{GroupId: 2, UserId: 1}}}
+	_, err = client.DeleteUser(ctx, &pb.DeleteUserRequest{Id: 1, Policy: pb.DeletePolicy_DELETE_POLICY_RESTRICT})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestLinkGroupToUser(t *testing.T) {
+	client := dial(t, newFakeStore())
+	ctx := context.Background()
+
+	m, err := client.LinkGroupToUser(ctx, &pb.LinkGroupToUserRequest{GroupId: 2, UserId: 1, Role: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", m.Role)
+	assert.Equal(t, map[string]interface{}{"title": "lead"}, m.Attributes.AsMap())
+
+	_, err = client.LinkGroupToUser(ctx, &pb.LinkGroupToUserRequest{GroupId: 2, UserId: 1})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.GetMembership(ctx, &pb.GetMembershipRequest{GroupId: 2, UserId: 5})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetUsersByGroupId(t *testing.T) {
+	store := newFakeStore()
+	client := dial(t, store)
+	ctx := context.Background()
+
+	stream, err := client.GetUsersByGroupId(ctx, &pb.GetUsersByGroupIdRequest{GroupId: 2})
+	require.NoError(t, err)
+
+	var ids []int64
+
+	for {
+		u, err := stream.Recv()
+
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+		ids = append(ids, u.Id)
+	}
+
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+	assert.Equal(t, []string{"", "next"}, store.cursors)
+
+	store.err = &data.Error{Msg: data.ErrConnectionFailed, Kind: data.ErrConnection}
+	stream, err = client.GetUsersByGroupId(ctx, &pb.GetUsersByGroupIdRequest{GroupId: 2})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = stream.Recv()
+		require.NoError(t, err)
+	}
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}